@@ -15,8 +15,10 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/rishyym0927/storehubx/internal/config"
 	"github.com/rishyym0927/storehubx/internal/db"
+	"github.com/rishyym0927/storehubx/internal/handlers"
 	"github.com/rishyym0927/storehubx/internal/middleware"
 	"github.com/rishyym0927/storehubx/internal/routes"
+	"github.com/rishyym0927/storehubx/internal/storage"
 	"github.com/joho/godotenv"
 	_ "github.com/rishyym0927/storehubx/docs" // Swagger generated docs
 	"github.com/gofiber/swagger"
@@ -29,6 +31,14 @@ func main() {
 	db.EnsureIndexes(db.Client)
 	defer db.Disconnect()
 
+	// Storage is optional for the API: without it, deletes skip purging published files.
+	uploader, err := storage.NewFromEnv()
+	if err != nil {
+		log.Println("⚠️  storage disabled:", err)
+	} else {
+		handlers.SetUploader(uploader)
+	}
+
 	app := fiber.New()
 
 	// 🔹 Global middlewares
@@ -43,6 +53,11 @@ func main() {
 
 	// 🔹 Register routes
 	routes.RegisterRoutes(app)
+	// filesystem storage is served by the API itself
+	if fsu, ok := uploader.(*storage.FSUploader); ok {
+		routes.RegisterStatic(app, fsu)
+		log.Println("📁 serving storage from", fsu.Root(), "on /static")
+	}
 
 	log.Println("🚀 StoreHUB running on port", config.AppConfig.Port)
 	log.Fatal(app.Listen(":" + config.AppConfig.Port))
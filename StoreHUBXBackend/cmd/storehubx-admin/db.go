@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/rishyym0927/storehubx/internal/artifact"
+	"github.com/rishyym0927/storehubx/internal/buildlog"
+	"github.com/rishyym0927/storehubx/internal/db"
+	"github.com/rishyym0927/storehubx/internal/models"
+	"github.com/rishyym0927/storehubx/internal/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// runReindex recreates the database indexes, then makes every version's
+// previewUrl match what storage actually holds: the store's URL for its
+// index.html, or nothing when the page is gone.
+func runReindex(args []string, rep *report) error {
+	_, parse := newFlags("reindex", rep, true)
+	if err := parse(args); err != nil {
+		return err
+	}
+	store, err := openStore()
+	if err != nil {
+		return err
+	}
+	database := openDB()
+
+	if rep.DryRun {
+		rep.record("ensure-indexes", database.Name(), "")
+	} else if err := db.EnsureIndexes(db.Client); err != nil {
+		rep.fail("indexes", err)
+	} else {
+		rep.record("ensure-indexes", database.Name(), "")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+	sc := scopeOf(rep)
+	comps, err := scopedComponents(ctx, database, sc)
+	if err != nil {
+		return err
+	}
+	verCol := database.Collection("component_versions")
+	for _, comp := range comps {
+		versions, err := scopedVersions(ctx, database, comp.ID, sc)
+		if err != nil {
+			rep.fail(comp.Slug, err)
+			continue
+		}
+		for _, ver := range versions {
+			target := comp.Slug + "@" + ver.Version
+			indexKey := path.Join("components", comp.Slug, ver.Version, "index.html")
+			_, err := store.Stat(ctx, indexKey)
+			switch {
+			case err == nil:
+				if want := store.PublicURL(indexKey); ver.PreviewURL != want {
+					if !rep.DryRun {
+						if _, err := verCol.UpdateOne(ctx, bson.M{"_id": ver.ID}, bson.M{"$set": bson.M{"previewUrl": want}}); err != nil {
+							rep.fail(target, err)
+							continue
+						}
+					}
+					rep.record("set-preview-url", target, want)
+				}
+			case errors.Is(err, storage.ErrNotFound):
+				if ver.PreviewURL != "" {
+					if !rep.DryRun {
+						if _, err := verCol.UpdateOne(ctx, bson.M{"_id": ver.ID}, bson.M{"$unset": bson.M{"previewUrl": ""}}); err != nil {
+							rep.fail(target, err)
+							continue
+						}
+					}
+					rep.record("unset-preview-url", target, "missing "+indexKey)
+				}
+			default:
+				rep.fail(indexKey, err)
+				continue
+			}
+
+			if ver.Source != nil {
+				if _, err := store.Stat(ctx, artifact.TarballKey(comp.Slug, ver.Version)); errors.Is(err, storage.ErrNotFound) {
+					rep.warn(target, "source tarball is missing; rebuild the version to restore installs")
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// runRebuildAll queues a build of every version in scope whose component is
+// linked to a repository. Versions with a build already queued or running
+// are skipped.
+func runRebuildAll(args []string, rep *report) error {
+	flags, parse := newFlags("rebuild-all", rep, true)
+	states := flags.String("state", "", "only rebuild versions in these build states (comma-separated, e.g. error,none)")
+	yanked := flags.Bool("include-yanked", false, "rebuild yanked versions too")
+	if err := parse(args); err != nil {
+		return err
+	}
+	want := map[models.BuildState]bool{}
+	for _, s := range strings.Split(*states, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			want[models.BuildState(s)] = true
+		}
+	}
+	database := openDB()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+	sc := scopeOf(rep)
+	comps, err := scopedComponents(ctx, database, sc)
+	if err != nil {
+		return err
+	}
+	verCol := database.Collection("component_versions")
+	jobCol := database.Collection("build_jobs")
+	logs := buildlog.NewStore(database.Collection("build_logs"))
+	for _, comp := range comps {
+		if comp.RepoLink.Owner == "" || comp.RepoLink.Repo == "" {
+			if sc.Component != "" {
+				rep.warn(comp.Slug, "not linked to a GitHub repo")
+			}
+			continue
+		}
+		versions, err := scopedVersions(ctx, database, comp.ID, sc)
+		if err != nil {
+			rep.fail(comp.Slug, err)
+			continue
+		}
+		for _, ver := range versions {
+			target := comp.Slug + "@" + ver.Version
+			state := ver.BuildState
+			if state == "" {
+				state = models.VersionBuildNone
+			}
+			if (len(want) > 0 && !want[state]) || (ver.Yanked && !*yanked) {
+				continue
+			}
+			active, err := jobCol.CountDocuments(ctx, bson.M{
+				"componentId": comp.ID,
+				"version":     ver.Version,
+				"status":      bson.M{"$in": []models.BuildStatus{models.BuildQueued, models.BuildRunning}},
+			})
+			if err != nil {
+				rep.fail(target, err)
+				continue
+			}
+			if active > 0 {
+				continue
+			}
+
+			commit := ver.CommitSHA
+			if commit == "" {
+				commit = comp.RepoLink.Commit
+			}
+			detail := fmt.Sprintf("%s/%s@%s", comp.RepoLink.Owner, comp.RepoLink.Repo, commit)
+			if commit == "" {
+				detail = fmt.Sprintf("%s/%s@%s", comp.RepoLink.Owner, comp.RepoLink.Repo, comp.RepoLink.Ref)
+			}
+			if !rep.DryRun {
+				job := models.BuildJob{
+					ComponentID: comp.ID,
+					Component:   comp.Slug,
+					Version:     ver.Version,
+					Status:      models.BuildQueued,
+					OwnerID:     comp.OwnerID,
+					Repo: models.BuildRepo{
+						Owner:  comp.RepoLink.Owner,
+						Repo:   comp.RepoLink.Repo,
+						Path:   comp.RepoLink.Path,
+						Ref:    comp.RepoLink.Ref,
+						Commit: commit,
+					},
+					CreatedAt: time.Now(),
+					UpdatedAt: time.Now(),
+				}
+				res, err := jobCol.InsertOne(ctx, job)
+				if err != nil {
+					rep.fail(target, err)
+					continue
+				}
+				oid, _ := res.InsertedID.(primitive.ObjectID)
+				_ = logs.Append(ctx, oid, buildlog.System, "", "enqueued - rebuild requested by storehubx-admin")
+				_, _ = verCol.UpdateOne(ctx, bson.M{"_id": ver.ID}, bson.M{"$set": bson.M{"buildState": models.VersionBuildQueued}})
+				detail = oid.Hex() + " " + detail
+			}
+			rep.record("enqueue-build", target, detail)
+		}
+	}
+	return nil
+}
+
+// scopedComponents returns the component named by the scope, or every
+// component when it names none, sorted by slug.
+func scopedComponents(ctx context.Context, database *mongo.Database, sc scope) ([]models.Component, error) {
+	filter := bson.M{}
+	if sc.Component != "" {
+		filter["slug"] = sc.Component
+	}
+	cur, err := database.Collection("components").Find(ctx, filter, options.Find().SetSort(bson.M{"slug": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("list components: %w", err)
+	}
+	var comps []models.Component
+	if err := cur.All(ctx, &comps); err != nil {
+		return nil, fmt.Errorf("list components: %w", err)
+	}
+	if sc.Component != "" && len(comps) == 0 {
+		return nil, fmt.Errorf("component %q not found", sc.Component)
+	}
+	return comps, nil
+}
+
+// scopedVersions returns a component's versions in scope, oldest first.
+func scopedVersions(ctx context.Context, database *mongo.Database, componentID primitive.ObjectID, sc scope) ([]models.ComponentVersion, error) {
+	filter := bson.M{"componentId": componentID}
+	if sc.Version != "" {
+		filter["version"] = sc.Version
+	}
+	cur, err := database.Collection("component_versions").Find(ctx, filter, options.Find().SetSort(bson.M{"createdAt": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("list versions: %w", err)
+	}
+	var versions []models.ComponentVersion
+	if err := cur.All(ctx, &versions); err != nil {
+		return nil, fmt.Errorf("list versions: %w", err)
+	}
+	if sc.Version != "" && len(versions) == 0 {
+		return nil, fmt.Errorf("version %q not found", sc.Version)
+	}
+	return versions, nil
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// exportFile is what export writes and import reads. Documents are kept in
+// canonical MongoDB Extended JSON so IDs, dates and numbers round-trip
+// exactly.
+type exportFile struct {
+	ExportedAt        time.Time         `json:"exportedAt"`
+	Components        []json.RawMessage `json:"components"`
+	ComponentVersions []json.RawMessage `json:"componentVersions"`
+}
+
+// exported lists the collections export covers, in the order import
+// restores them.
+var exported = []string{"components", "component_versions"}
+
+// runExport writes the components in scope and their versions to --out.
+func runExport(args []string, rep *report) error {
+	flags, parse := newFlags("export", rep, true)
+	out := flags.String("out", "storehubx-export.json", "file to write")
+	if err := parse(args); err != nil {
+		return err
+	}
+	database := openDB()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	sc := scopeOf(rep)
+	compFilter := bson.M{}
+	if sc.Component != "" {
+		compFilter["slug"] = sc.Component
+	}
+	comps, err := findDocs(ctx, database.Collection("components"), compFilter)
+	if err != nil {
+		return err
+	}
+	ids := make([]interface{}, 0, len(comps))
+	for _, comp := range comps {
+		ids = append(ids, comp.Lookup("_id"))
+	}
+	verFilter := bson.M{"componentId": bson.M{"$in": ids}}
+	if sc.Version != "" {
+		verFilter["version"] = sc.Version
+	}
+	versions, err := findDocs(ctx, database.Collection("component_versions"), verFilter)
+	if err != nil {
+		return err
+	}
+
+	file := exportFile{ExportedAt: time.Now().UTC()}
+	if file.Components, err = extJSON(comps); err != nil {
+		return err
+	}
+	if file.ComponentVersions, err = extJSON(versions); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	if !rep.DryRun {
+		if err := os.WriteFile(*out, append(data, '\n'), 0o600); err != nil {
+			return err
+		}
+	}
+	rep.Summary["components"] = int64(len(comps))
+	rep.Summary["componentVersions"] = int64(len(versions))
+	rep.record("write-export", *out, fmt.Sprintf("%d components, %d versions, %d bytes", len(comps), len(versions), len(data)))
+	return nil
+}
+
+// runImport upserts the documents of an export file by _id. Scoping selects
+// which of the file's components (and their versions) are restored.
+func runImport(args []string, rep *report) error {
+	flags, parse := newFlags("import", rep, true)
+	if err := parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: storehubx-admin import [flags] FILE")
+	}
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	var file exportFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("%s: %w", flags.Arg(0), err)
+	}
+	database := openDB()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	sc := scopeOf(rep)
+	slugs := map[primitive.ObjectID]string{} // components in scope
+	for i, docs := range [][]json.RawMessage{file.Components, file.ComponentVersions} {
+		col := database.Collection(exported[i])
+		for _, raw := range docs {
+			var doc bson.D
+			if err := bson.UnmarshalExtJSON(raw, true, &doc); err != nil {
+				rep.fail(exported[i], err)
+				continue
+			}
+			id, _ := field(doc, "_id").(primitive.ObjectID)
+			if id.IsZero() {
+				rep.fail(exported[i], fmt.Errorf("document without an ObjectID _id"))
+				continue
+			}
+			var target string
+			if exported[i] == "components" {
+				slug, _ := field(doc, "slug").(string)
+				if sc.Component != "" && slug != sc.Component {
+					continue
+				}
+				slugs[id] = slug
+				target = slug
+			} else {
+				compID, _ := field(doc, "componentId").(primitive.ObjectID)
+				version, _ := field(doc, "version").(string)
+				slug, ok := slugs[compID]
+				if !ok || (sc.Version != "" && version != sc.Version) {
+					continue
+				}
+				target = slug + "@" + version
+			}
+
+			action := "insert"
+			if n, err := col.CountDocuments(ctx, bson.M{"_id": id}); err != nil {
+				rep.fail(target, err)
+				continue
+			} else if n > 0 {
+				action = "replace"
+			}
+			if !rep.DryRun {
+				if _, err := col.ReplaceOne(ctx, bson.M{"_id": id}, doc, options.Replace().SetUpsert(true)); err != nil {
+					rep.fail(target, err)
+					continue
+				}
+			}
+			rep.record(action+"-"+exported[i], target, "")
+		}
+	}
+	return nil
+}
+
+// field returns the value of a top-level field, or nil.
+func field(doc bson.D, key string) interface{} {
+	for _, e := range doc {
+		if e.Key == key {
+			return e.Value
+		}
+	}
+	return nil
+}
+
+func findDocs(ctx context.Context, col *mongo.Collection, filter bson.M) ([]bson.Raw, error) {
+	cur, err := col.Find(ctx, filter, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", col.Name(), err)
+	}
+	var docs []bson.Raw
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("%s: %w", col.Name(), err)
+	}
+	return docs, nil
+}
+
+func extJSON(docs []bson.Raw) ([]json.RawMessage, error) {
+	out := make([]json.RawMessage, 0, len(docs))
+	for _, doc := range docs {
+		b, err := bson.MarshalExtJSON(doc, true, false)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rishyym0927/storehubx/internal/gc"
+)
+
+// runGC deletes stored artifacts nothing refers to any more; see package gc
+// for the rules.
+func runGC(args []string, rep *report) error {
+	flags, parse := newFlags("gc", rep, true)
+	grace := flags.Duration("grace", gc.DefaultGrace, "keep unreferenced objects modified within this long")
+	if err := parse(args); err != nil {
+		return err
+	}
+	store, err := openStore()
+	if err != nil {
+		return err
+	}
+	database := openDB()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+	sc := scopeOf(rep)
+	res, err := gc.New(database, store).Run(ctx, gc.Options{
+		Grace:     *grace,
+		Component: sc.Component,
+		Version:   sc.Version,
+		DryRun:    rep.DryRun,
+	})
+	if err != nil {
+		return err
+	}
+
+	rep.Summary["scannedObjects"] = int64(res.Scanned)
+	rep.Summary["scannedBytes"] = res.ScannedBytes
+	for _, a := range res.Collected {
+		if a.Error != "" {
+			rep.fail(a.Prefix, errors.New(a.Error))
+			continue
+		}
+		action := "delete-prefix"
+		if a.Single {
+			action = "delete-object"
+		}
+		rep.reclaim(action, a.Prefix, fmt.Sprintf("%s (%d objects)", a.Reason, a.Objects), a.Bytes)
+	}
+	for _, a := range res.Recent {
+		rep.warn(a.Prefix, fmt.Sprintf("%s, but modified %s ago; kept for the grace period", a.Reason, time.Since(a.LastModified).Round(time.Minute)))
+	}
+	rep.Summary["recent"] = int64(len(res.Recent))
+	return nil
+}
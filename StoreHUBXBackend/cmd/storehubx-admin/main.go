@@ -0,0 +1,244 @@
+// Command storehubx-admin runs maintenance tasks against the database and
+// object store the API and worker use.
+//
+//	storehubx-admin bucket-policy      make the S3 bucket publicly readable
+//	storehubx-admin fix-mime           repair stored content types
+//	storehubx-admin fix-html           point index.html asset URLs at the published assets
+//	storehubx-admin reindex            recreate indexes and reconcile versions with storage
+//	storehubx-admin gc                 delete stored files nothing refers to any more
+//	storehubx-admin rebuild-all        queue a build for every linked version
+//	storehubx-admin export --out FILE  dump components and versions as JSON
+//	storehubx-admin import FILE        upsert components and versions from an export
+//
+// Every command accepts --dry-run, and --component/--version where they
+// apply. The report of what changed is printed to stdout as JSON; progress
+// goes to stderr. Configuration comes from the same environment (and .env)
+// as the API: MONGO_URI, STORAGE_BACKEND and the S3_*/STORAGE_* variables.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/rishyym0927/storehubx/internal/config"
+	"github.com/rishyym0927/storehubx/internal/db"
+	"github.com/rishyym0927/storehubx/internal/storage"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// command is one subcommand. It records what it did (or would do) in rep and
+// returns an error only when it could not run at all.
+type command func(args []string, rep *report) error
+
+var commands = map[string]command{
+	"bucket-policy": runBucketPolicy,
+	"fix-mime":      runFixMime,
+	"fix-html":      runFixHTML,
+	"reindex":       runReindex,
+	"gc":            runGC,
+	"rebuild-all":   runRebuildAll,
+	"export":        runExport,
+	"import":        runImport,
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("storehubx-admin: ")
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "--help" {
+		usage()
+		return
+	}
+	run, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "storehubx-admin: unknown command %q\n\n", name)
+		usage()
+		os.Exit(2)
+	}
+
+	_ = godotenv.Load()
+	rep := &report{Command: name, StartedAt: time.Now().UTC(), Changes: []change{}, Summary: map[string]int64{}}
+	err := run(os.Args[2:], rep)
+	if err == flag.ErrHelp {
+		return
+	}
+	if err != nil {
+		rep.fail("", err)
+	}
+	rep.FinishedAt = time.Now().UTC()
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(rep); err != nil {
+		log.Fatal(err)
+	}
+	if db.Client != nil {
+		db.Disconnect()
+	}
+	if len(rep.Errors) > 0 {
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprint(os.Stderr, `Usage: storehubx-admin <command> [flags]
+
+Commands:
+  bucket-policy  make the S3 bucket publicly readable (S3 backend only)
+  fix-mime       give stored objects the content type their extension maps to
+  fix-html       rewrite index.html asset URLs to the relative paths publishing uses
+  reindex        recreate database indexes and reconcile preview URLs with storage
+  gc             delete stored files nothing refers to any more, after a grace period
+  rebuild-all    queue a build for every version of every linked component
+  export         write components and their versions to a JSON file
+  import         upsert components and versions from an export file
+
+Common flags:
+  --dry-run              report what would change without changing anything
+  --component SLUG       only touch this component
+  --version VERSION      only touch this version (needs --component)
+
+The report is printed to stdout as JSON. Run "storehubx-admin <command> -h"
+for the flags of a command.
+`)
+}
+
+// report is the machine-readable result of one run.
+type report struct {
+	Command    string           `json:"command"`
+	DryRun     bool             `json:"dryRun"`
+	Scope      *scope           `json:"scope,omitempty"`
+	StartedAt  time.Time        `json:"startedAt"`
+	FinishedAt time.Time        `json:"finishedAt"`
+	Changes    []change         `json:"changes"`
+	Warnings   []string         `json:"warnings,omitempty"`
+	Errors     []string         `json:"errors,omitempty"`
+	Summary    map[string]int64 `json:"summary"`
+}
+
+// change is one thing a command changed, or would change in a dry run.
+type change struct {
+	Action string `json:"action"`           // e.g. "set-content-type", "delete-prefix"
+	Target string `json:"target"`           // object key, slug or document ID
+	Detail string `json:"detail,omitempty"` // human-readable specifics
+	Bytes  int64  `json:"bytes,omitempty"`  // bytes removed, where it applies
+}
+
+// record adds a change and counts it under its action in the summary.
+func (r *report) record(action, target, detail string) {
+	r.add(change{Action: action, Target: target, Detail: detail})
+}
+
+// reclaim records a deletion that frees bytes; the summary's "bytes" is
+// the total.
+func (r *report) reclaim(action, target, detail string, bytes int64) {
+	r.add(change{Action: action, Target: target, Detail: detail, Bytes: bytes})
+	r.Summary["bytes"] += bytes
+}
+
+func (r *report) add(c change) {
+	r.Changes = append(r.Changes, c)
+	r.Summary[c.Action]++
+	verb := "did"
+	if r.DryRun {
+		verb = "would"
+	}
+	log.Printf("%s %s %s %s", verb, c.Action, c.Target, c.Detail)
+}
+
+// warn records something worth a look that the command does not fix.
+func (r *report) warn(target, msg string) {
+	r.Warnings = append(r.Warnings, target+": "+msg)
+	log.Print("warning: ", target, ": ", msg)
+}
+
+// fail records an error that did not stop the run; target may be empty.
+func (r *report) fail(target string, err error) {
+	msg := err.Error()
+	if target != "" {
+		msg = target + ": " + msg
+	}
+	r.Errors = append(r.Errors, msg)
+	log.Print("error: ", msg)
+}
+
+// scope is the --component/--version selection shared by every command.
+type scope struct {
+	Component string `json:"component,omitempty"`
+	Version   string `json:"version,omitempty"`
+}
+
+// prefix is the storage prefix the scope covers.
+func (s scope) prefix() string {
+	p := "components/"
+	if s.Component != "" {
+		p += s.Component + "/"
+		if s.Version != "" {
+			p += s.Version + "/"
+		}
+	}
+	return p
+}
+
+// newFlags returns a flag set with --dry-run and, when scoped is set,
+// --component and --version. parse fills rep and validates the scope.
+func newFlags(name string, rep *report, scoped bool) (*flag.FlagSet, func(args []string) error) {
+	flags := flag.NewFlagSet("storehubx-admin "+name, flag.ContinueOnError)
+	flags.BoolVar(&rep.DryRun, "dry-run", false, "report what would change without changing anything")
+	var sc scope
+	if scoped {
+		flags.StringVar(&sc.Component, "component", "", "only touch this component (slug)")
+		flags.StringVar(&sc.Version, "version", "", "only touch this version (needs --component)")
+	}
+	parse := func(args []string) error {
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+		if !scoped {
+			return nil
+		}
+		sc.Component = strings.Trim(sc.Component, "/")
+		if sc.Version != "" && sc.Component == "" {
+			return fmt.Errorf("--version needs --component")
+		}
+		if sc.Component != "" {
+			rep.Scope = &sc
+		}
+		return nil
+	}
+	return flags, parse
+}
+
+// scopeOf returns the scope parsed into rep, or the empty scope.
+func scopeOf(rep *report) scope {
+	if rep.Scope == nil {
+		return scope{}
+	}
+	return *rep.Scope
+}
+
+// openStore returns the backend selected by STORAGE_BACKEND.
+func openStore() (storage.Uploader, error) {
+	store, err := storage.NewFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("storage: %w", err)
+	}
+	return store, nil
+}
+
+// openDB connects to MONGO_URI and returns the database the API uses.
+func openDB() *mongo.Database {
+	config.LoadConfig()
+	db.Init(config.AppConfig.MongoURI)
+	return db.Client.Database(db.Name)
+}
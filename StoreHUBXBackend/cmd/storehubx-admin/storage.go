@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/rishyym0927/storehubx/internal/storage"
+	"golang.org/x/net/html"
+)
+
+// runBucketPolicy applies the public read policy previews need when they
+// are served straight from the bucket.
+func runBucketPolicy(args []string, rep *report) error {
+	_, parse := newFlags("bucket-policy", rep, false)
+	if err := parse(args); err != nil {
+		return err
+	}
+	store, err := openStore()
+	if err != nil {
+		return err
+	}
+	s3, ok := store.(*storage.S3Uploader)
+	if !ok {
+		return fmt.Errorf("bucket-policy only applies to the s3 storage backend")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if !rep.DryRun {
+		if err := s3.SetPublicReadPolicy(ctx); err != nil {
+			return fmt.Errorf("set bucket policy: %w", err)
+		}
+	}
+	rep.record("set-bucket-policy", s3.Bucket(), "public read")
+	return nil
+}
+
+// runFixMime gives objects the content type their extension maps to.
+func runFixMime(args []string, rep *report) error {
+	_, parse := newFlags("fix-mime", rep, true)
+	if err := parse(args); err != nil {
+		return err
+	}
+	store, err := openStore()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+	stale, err := storage.StaleContentTypes(ctx, store, scopeOf(rep).prefix())
+	if err != nil {
+		return err
+	}
+	for _, fix := range stale {
+		if !rep.DryRun {
+			if err := store.SetMetadata(ctx, fix.Key, fix.To, nil); err != nil {
+				rep.fail(fix.Key, err)
+				continue
+			}
+		}
+		rep.record("set-content-type", fix.Key, fmt.Sprintf("%s -> %s", fix.From, fix.To))
+	}
+	return nil
+}
+
+// runFixHTML rewrites asset URLs in published HTML pages to the relative
+// "assets/..." paths publishing produces today. Pages uploaded by older
+// workers (or "fixed" by older tools) point at /assets/, at the bucket root
+// or at a hard-coded host, which breaks once the bucket moves.
+func runFixHTML(args []string, rep *report) error {
+	_, parse := newFlags("fix-html", rep, true)
+	if err := parse(args); err != nil {
+		return err
+	}
+	store, err := openStore()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+	objects, err := store.List(ctx, scopeOf(rep).prefix())
+	if err != nil {
+		return fmt.Errorf("list objects: %w", err)
+	}
+	keys := make(map[string]bool, len(objects))
+	for _, obj := range objects {
+		keys[obj.Key] = true
+	}
+
+	for _, obj := range objects {
+		if !strings.HasSuffix(strings.ToLower(obj.Key), ".html") {
+			continue
+		}
+		dir := path.Dir(obj.Key)
+		if strings.Count(dir, "/") < 2 {
+			continue // not inside components/<slug>/<version>/
+		}
+		content, err := store.Get(ctx, obj.Key)
+		if err != nil {
+			rep.fail(obj.Key, err)
+			continue
+		}
+		fixed, rewrites, err := relativeAssetURLs(content, func(rel string) bool {
+			return keys[path.Join(dir, rel)]
+		})
+		if err != nil {
+			rep.fail(obj.Key, err)
+			continue
+		}
+		if rewrites == 0 {
+			continue
+		}
+		if !rep.DryRun {
+			if _, err := store.Put(ctx, obj.Key, fixed, "text/html"); err != nil {
+				rep.fail(obj.Key, err)
+				continue
+			}
+		}
+		rep.record("rewrite-html", obj.Key, fmt.Sprintf("%d asset URLs", rewrites))
+	}
+	return nil
+}
+
+// relativeAssetURLs rewrites src and href attributes that reach into an
+// assets/ folder to "assets/<rest>" when exists reports that path next to
+// the page. Query strings and fragments are kept. It returns the rendered
+// page and the number of attributes changed.
+func relativeAssetURLs(page []byte, exists func(rel string) bool) ([]byte, int, error) {
+	doc, err := html.Parse(bytes.NewReader(page))
+	if err != nil {
+		return nil, 0, fmt.Errorf("parse html: %w", err)
+	}
+
+	rewrites := 0
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			for i := range n.Attr {
+				attr := &n.Attr[i]
+				if key := strings.ToLower(attr.Key); key != "src" && key != "href" {
+					continue
+				}
+				val := strings.TrimSpace(attr.Val)
+				suffix := ""
+				if q := strings.IndexAny(val, "?#"); q >= 0 {
+					val, suffix = val[:q], val[q:]
+				}
+				at := strings.Index(val, "assets/")
+				if at < 0 || (at > 0 && val[at-1] != '/') {
+					continue
+				}
+				rel := path.Clean("assets/" + val[at+len("assets/"):])
+				if !strings.HasPrefix(rel, "assets/") || !exists(rel) {
+					continue
+				}
+				if rel+suffix != attr.Val {
+					attr.Val = rel + suffix
+					rewrites++
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	if rewrites == 0 {
+		return page, 0, nil
+	}
+	var buf bytes.Buffer
+	if err := html.Render(&buf, doc); err != nil {
+		return nil, 0, fmt.Errorf("render html: %w", err)
+	}
+	return buf.Bytes(), rewrites, nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rishyym0927/storehubx/internal/models"
+)
+
+// maxDownload bounds a single tarball download.
+const maxDownload = 512 << 20
+
+type client struct {
+	base  string
+	token string
+	http  *http.Client
+}
+
+func newClient(base string) *client {
+	return &client{
+		base: strings.TrimRight(base, "/"),
+		http: &http.Client{Timeout: 2 * time.Minute},
+	}
+}
+
+// envelope is the {success, data, error} wrapper every API response uses.
+type envelope struct {
+	Success bool            `json:"success"`
+	Data    json.RawMessage `json:"data"`
+	Error   string          `json:"error"`
+}
+
+type resolveResult struct {
+	Component  string                  `json:"component"`
+	Range      string                  `json:"range"`
+	Version    models.ComponentVersion `json:"version"`
+	Deprecated string                  `json:"deprecated"`
+}
+
+func (c *client) resolve(ctx context.Context, slug, ref string) (*resolveResult, error) {
+	var out resolveResult
+	path := "/components/" + url.PathEscape(slug) + "/resolve?range=" + url.QueryEscape(ref)
+	if err := c.do(ctx, http.MethodGet, path, nil, &out); err != nil {
+		return nil, fmt.Errorf("resolve %s@%s: %w", slug, ref, err)
+	}
+	return &out, nil
+}
+
+// do calls the API and decodes the data of its response envelope into out.
+func (c *client) do(ctx context.Context, method, path string, body, out any) error {
+	var rd io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		rd = strings.NewReader(string(b))
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, rd)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var env envelope
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 16<<20)).Decode(&env); err != nil {
+		return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+	if resp.StatusCode >= 300 || !env.Success {
+		if env.Error == "" {
+			env.Error = resp.Status
+		}
+		return fmt.Errorf("%s (HTTP %d)", env.Error, resp.StatusCode)
+	}
+	if out != nil && len(env.Data) > 0 {
+		return json.Unmarshal(env.Data, out)
+	}
+	return nil
+}
+
+// download fetches an artifact by its public URL.
+func (c *client) download(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download %s: %s", rawURL, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDownload+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxDownload {
+		return nil, fmt.Errorf("download %s: larger than %d bytes", rawURL, maxDownload)
+	}
+	return data, nil
+}
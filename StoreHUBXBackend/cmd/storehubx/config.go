@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// config is the per-user state written by "storehubx login".
+type config struct {
+	API      string `json:"api,omitempty"`
+	Token    string `json:"token,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+// configPath is $XDG_CONFIG_HOME/storehubx/config.json or the platform
+// equivalent; STOREHUBX_CONFIG overrides it.
+func configPath() (string, error) {
+	if p := os.Getenv("STOREHUBX_CONFIG"); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "storehubx", "config.json"), nil
+}
+
+// loadConfig returns the saved config, or an empty one when there is none.
+func loadConfig() (*config, error) {
+	p, err := configPath()
+	if err != nil {
+		return &config{}, nil
+	}
+	b, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return &config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cfg config
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", p, err)
+	}
+	return &cfg, nil
+}
+
+// save writes the config readable by the current user only, since it holds
+// the API token.
+func (cfg *config) save() (string, error) {
+	p, err := configPath()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		return "", err
+	}
+	b, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return p, os.WriteFile(p, append(b, '\n'), 0o600)
+}
+
+// authClient returns an API client carrying the token from $STOREHUBX_TOKEN
+// or the saved login.
+func authClient(api string) (*client, error) {
+	c := newClient(api)
+	c.token = os.Getenv("STOREHUBX_TOKEN")
+	if c.token == "" {
+		cfg, err := loadConfig()
+		if err != nil {
+			return nil, err
+		}
+		c.token = cfg.Token
+	}
+	if c.token == "" {
+		return nil, errors.New(`not logged in: run "storehubx login" or set STOREHUBX_TOKEN`)
+	}
+	return c, nil
+}
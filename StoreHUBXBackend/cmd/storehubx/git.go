@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// gitHubRemote matches https, ssh and scp-style GitHub remotes.
+var gitHubRemote = regexp.MustCompile(`^(?:https?://(?:[^@/]+@)?github\.com/|ssh://git@github\.com/|git@github\.com:)([^/]+)/([^/]+?)(?:\.git)?/?$`)
+
+func git(args ...string) (string, error) {
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		var exit *exec.ExitError
+		if errors.As(err, &exit) && len(exit.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s", strings.Join(args, " "), strings.TrimSpace(string(exit.Stderr)))
+		}
+		return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// parseGitHubRemote extracts owner and repo from a remote URL.
+func parseGitHubRemote(remote string) (owner, repo string, err error) {
+	m := gitHubRemote.FindStringSubmatch(strings.TrimSpace(remote))
+	if m == nil {
+		return "", "", fmt.Errorf("remote %q is not a GitHub repository", remote)
+	}
+	return m[1], m[2], nil
+}
+
+// currentRef is the checked-out branch. CI systems check out a detached
+// HEAD, so their branch variables are consulted before giving up.
+func currentRef() (string, error) {
+	ref, err := git("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	if ref != "HEAD" {
+		return ref, nil
+	}
+	for _, env := range []string{"GITHUB_HEAD_REF", "GITHUB_REF_NAME", "CI_COMMIT_REF_NAME"} {
+		if v := os.Getenv(env); v != "" {
+			return v, nil
+		}
+	}
+	return "", errors.New("HEAD is detached; pass --ref")
+}
+
+// warnUnpublished points out that builds fetch the commit from GitHub, so
+// local changes and unpushed commits are not part of it.
+func warnUnpublished(commit string) {
+	if out, err := git("status", "--porcelain"); err == nil && out != "" {
+		fmt.Fprintln(os.Stderr, "warning: the working tree has uncommitted changes; only the committed state is published")
+	}
+	if out, err := git("branch", "-r", "--contains", commit); err == nil && out == "" {
+		fmt.Fprintf(os.Stderr, "warning: %s is not on any remote branch; push it before the build runs\n", shortSHA(commit))
+	}
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rishyym0927/storehubx/internal/artifact"
+)
+
+var errConflict = errors.New("locally modified files would be overwritten; rerun with --force to overwrite or --keep to keep them")
+
+// installer applies installs and removals to the working tree and lockfile.
+type installer struct {
+	client *client
+	lock   *lockfile
+	dir    string // base folder for new installs ("" = keep each entry's folder)
+	force  bool   // overwrite or delete modified files without asking
+	keep   bool   // keep modified files without asking
+	stdin  *bufio.Reader
+}
+
+// fileAction is one planned write.
+type fileAction struct {
+	target string
+	entry  artifact.Entry
+}
+
+func runInstall(args []string) error {
+	flags := flag.NewFlagSet("install", flag.ExitOnError)
+	api := apiFlag(flags)
+	dir := flags.String("dir", "", `folder components are installed into, one subfolder each (default "components")`)
+	force := flags.Bool("force", false, "overwrite locally modified files without asking")
+	keep := flags.Bool("keep", false, "keep locally modified files without asking")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: storehubx install [flags] [<slug>[@version|@range|@tag] ...]")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+	if *force && *keep {
+		return errors.New("--force and --keep are mutually exclusive")
+	}
+
+	lock, err := readLockfile(lockfileName)
+	if err != nil {
+		return err
+	}
+	in := &installer{
+		client: newClient(*api),
+		lock:   lock,
+		dir:    *dir,
+		force:  *force,
+		keep:   *keep,
+		stdin:  bufio.NewReader(os.Stdin),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	if flags.NArg() == 0 {
+		err = in.restore(ctx)
+	} else {
+		for _, spec := range flags.Args() {
+			slug, ref := splitSpec(spec)
+			if err = in.install(ctx, slug, ref); err != nil {
+				break
+			}
+		}
+	}
+	// keep what did get installed on record even when a later spec failed
+	if werr := lock.write(); err == nil {
+		err = werr
+	}
+	return err
+}
+
+func runRemove(args []string) error {
+	flags := flag.NewFlagSet("remove", flag.ExitOnError)
+	force := flags.Bool("force", false, "delete locally modified files too")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: storehubx remove [flags] <slug> ...")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	lock, err := readLockfile(lockfileName)
+	if err != nil {
+		return err
+	}
+	in := &installer{lock: lock, force: *force, keep: !*force, stdin: bufio.NewReader(os.Stdin)}
+	for _, slug := range flags.Args() {
+		entry, ok := lock.Components[slug]
+		if !ok {
+			return fmt.Errorf("%s is not installed", slug)
+		}
+		in.removeFiles(entry, entry.Files, nil)
+		delete(lock.Components, slug)
+		fmt.Printf("- %s@%s\n", slug, entry.Version)
+	}
+	return lock.write()
+}
+
+// install resolves slug@ref against the API and installs the result.
+func (in *installer) install(ctx context.Context, slug, ref string) error {
+	res, err := in.client.resolve(ctx, slug, ref)
+	if err != nil {
+		return err
+	}
+	v := res.Version
+	if res.Deprecated != "" {
+		fmt.Fprintf(os.Stderr, "warning: %s is deprecated: %s\n", slug, res.Deprecated)
+	}
+	if v.Deprecated != "" {
+		fmt.Fprintf(os.Stderr, "warning: %s@%s is deprecated: %s\n", slug, v.Version, v.Deprecated)
+	}
+	if v.Yanked {
+		fmt.Fprintf(os.Stderr, "warning: %s@%s was yanked: %s\n", slug, v.Version, firstNonEmpty(v.YankReason, "no reason given"))
+	}
+	if v.Source == nil {
+		return fmt.Errorf("%s@%s has no installable source yet (its build has not produced one)", slug, v.Version)
+	}
+
+	prev := in.lock.Components[slug]
+	next := &lockEntry{
+		Version:   v.Version,
+		Requested: ref,
+		Resolved:  v.Source.TarballURL,
+		Integrity: v.Source.Integrity,
+		Commit:    v.Source.Commit,
+		Dir:       in.installDir(slug, prev),
+	}
+	if prev != nil && prev.Integrity == next.Integrity && prev.Dir == next.Dir && in.intact(prev) {
+		prev.Requested = ref
+		fmt.Printf("= %s@%s is up to date\n", slug, v.Version)
+		return nil
+	}
+	return in.apply(ctx, slug, prev, next)
+}
+
+// restore brings every lockfile entry back to exactly the pinned tarball.
+func (in *installer) restore(ctx context.Context) error {
+	slugs := make([]string, 0, len(in.lock.Components))
+	for slug := range in.lock.Components {
+		slugs = append(slugs, slug)
+	}
+	sort.Strings(slugs)
+	if len(slugs) == 0 {
+		fmt.Println("nothing to install: no components in " + lockfileName)
+		return nil
+	}
+
+	for _, slug := range slugs {
+		entry := in.lock.Components[slug]
+		if in.intact(entry) {
+			fmt.Printf("= %s@%s\n", slug, entry.Version)
+			continue
+		}
+		next := *entry
+		next.Files = nil
+		if err := in.apply(ctx, slug, entry, &next); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply downloads and verifies next's tarball, writes its files, removes
+// files only prev had, and records next in the lockfile.
+func (in *installer) apply(ctx context.Context, slug string, prev, next *lockEntry) error {
+	data, err := in.client.download(ctx, next.Resolved)
+	if err != nil {
+		return err
+	}
+	entries, err := artifact.Unpack(bytes.NewReader(data), next.Integrity)
+	if err != nil {
+		return fmt.Errorf("%s@%s: %w", slug, next.Version, err)
+	}
+
+	var prevFiles map[string]string
+	if prev != nil && prev.Dir == next.Dir {
+		prevFiles = prev.Files
+	}
+
+	// plan everything first so a conflict aborts before anything is written
+	next.Files = make(map[string]string, len(entries))
+	var writes []fileAction
+	for _, e := range entries {
+		sum := sha256.Sum256(e.Data)
+		want := hex.EncodeToString(sum[:])
+		next.Files[e.Path] = want
+		target := filepath.Join(filepath.FromSlash(next.Dir), filepath.FromSlash(e.Path))
+		write, err := in.mayWrite(target, want, prevFiles[e.Path])
+		if err != nil {
+			return fmt.Errorf("%s: %w", slug, err)
+		}
+		if write {
+			writes = append(writes, fileAction{target: target, entry: e})
+		}
+	}
+
+	for _, w := range writes {
+		if err := os.MkdirAll(filepath.Dir(w.target), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(w.target, w.entry.Data, w.entry.Mode); err != nil {
+			return err
+		}
+	}
+	if prev != nil {
+		keep := next.Files
+		if prev.Dir != next.Dir {
+			keep = nil // moved: everything in the old folder goes
+		}
+		in.removeFiles(prev, prev.Files, keep)
+	}
+
+	in.lock.Components[slug] = next
+	fmt.Printf("+ %s@%s -> %s (%d files, %d written)\n", slug, next.Version, next.Dir, len(entries), len(writes))
+	return nil
+}
+
+// mayWrite decides whether target should be (over)written with content whose
+// hash is want. locked is the hash recorded when the file was last installed.
+func (in *installer) mayWrite(target, want, locked string) (bool, error) {
+	cur, err := fileHash(target)
+	if errors.Is(err, fs.ErrNotExist) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if cur == want {
+		return false, nil
+	}
+	if cur == locked {
+		return true, nil // untouched since the last install
+	}
+	return in.resolveConflict(fmt.Sprintf("%s has local changes. Overwrite?", target))
+}
+
+// removeFiles deletes files of old that keep does not list, sparing files the
+// user changed since they were installed, then prunes emptied folders.
+func (in *installer) removeFiles(old *lockEntry, files, keep map[string]string) {
+	root := filepath.FromSlash(old.Dir)
+	dirs := map[string]bool{}
+	for p, sum := range files {
+		if _, ok := keep[p]; ok {
+			continue
+		}
+		target := filepath.Join(root, filepath.FromSlash(p))
+		cur, err := fileHash(target)
+		if err != nil {
+			continue // already gone
+		}
+		if cur != sum {
+			if ok, err := in.resolveConflict(fmt.Sprintf("%s has local changes. Delete it anyway?", target)); err != nil || !ok {
+				fmt.Fprintf(os.Stderr, "warning: kept modified %s\n", target)
+				continue
+			}
+		}
+		if err := os.Remove(target); err == nil {
+			for d := path.Dir(p); d != "."; d = path.Dir(d) {
+				dirs[d] = true
+			}
+		}
+	}
+
+	// deepest first, so parents are empty by the time they are tried
+	sorted := make([]string, 0, len(dirs))
+	for d := range dirs {
+		sorted = append(sorted, d)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return strings.Count(sorted[i], "/") > strings.Count(sorted[j], "/") })
+	for _, d := range sorted {
+		_ = os.Remove(filepath.Join(root, filepath.FromSlash(d))) // fails unless empty
+	}
+	_ = os.Remove(root)
+}
+
+// resolveConflict applies --force/--keep, or asks when attached to a terminal.
+func (in *installer) resolveConflict(question string) (bool, error) {
+	switch {
+	case in.force:
+		return true, nil
+	case in.keep:
+		return false, nil
+	case !isTerminal(os.Stdin):
+		return false, errConflict
+	}
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	answer, _ := in.stdin.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
+
+// intact reports whether every file of entry is on disk as installed.
+func (in *installer) intact(entry *lockEntry) bool {
+	if len(entry.Files) == 0 {
+		return false
+	}
+	root := filepath.FromSlash(entry.Dir)
+	for p, sum := range entry.Files {
+		if cur, err := fileHash(filepath.Join(root, filepath.FromSlash(p))); err != nil || cur != sum {
+			return false
+		}
+	}
+	return true
+}
+
+// installDir is where slug's files go: --dir/<name>, else the folder of the
+// previous install, else components/<name>.
+func (in *installer) installDir(slug string, prev *lockEntry) string {
+	name := slug[strings.LastIndex(slug, "/")+1:]
+	switch {
+	case in.dir != "":
+		return path.Join(filepath.ToSlash(in.dir), name)
+	case prev != nil:
+		return prev.Dir
+	}
+	return path.Join("components", name)
+}
+
+func fileHash(p string) (string, error) {
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	// /dev/null is a character device too
+	if null, err := os.Stat(os.DevNull); err == nil && os.SameFile(info, null) {
+		return false
+	}
+	return true
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
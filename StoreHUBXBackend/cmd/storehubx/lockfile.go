@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const lockfileName = "storehubx-lock.json"
+
+// lockfile pins every installed component to an exact version, tarball and
+// integrity, and remembers the files it wrote so updates and removals only
+// touch what the install created.
+type lockfile struct {
+	LockfileVersion int                   `json:"lockfileVersion"`
+	Components      map[string]*lockEntry `json:"components"` // by slug
+
+	path string
+}
+
+type lockEntry struct {
+	Version   string            `json:"version"`
+	Requested string            `json:"requested,omitempty"` // range or tag asked for
+	Resolved  string            `json:"resolved"`            // tarball URL
+	Integrity string            `json:"integrity"`           // "sha256-<base64>" of the tarball
+	Commit    string            `json:"commit,omitempty"`
+	Dir       string            `json:"dir"`   // install folder, slash-separated, relative to the lockfile
+	Files     map[string]string `json:"files"` // path within Dir -> sha256 hex as installed
+}
+
+func readLockfile(path string) (*lockfile, error) {
+	lock := &lockfile{LockfileVersion: 1, Components: map[string]*lockEntry{}, path: path}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return lock, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, lock); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if lock.LockfileVersion != 1 {
+		return nil, fmt.Errorf("%s: unsupported lockfileVersion %d", path, lock.LockfileVersion)
+	}
+	if lock.Components == nil {
+		lock.Components = map[string]*lockEntry{}
+	}
+	return lock, nil
+}
+
+// write saves the lockfile atomically; map keys are sorted by encoding/json,
+// so unchanged installs produce identical files.
+func (l *lockfile) write() error {
+	b, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(l.path), ".storehubx-lock-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(append(b, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), l.path)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+func runLogin(args []string) error {
+	flags := flag.NewFlagSet("login", flag.ExitOnError)
+	api := apiFlag(flags)
+	token := flags.String("token", "", "API token (JWT); read from $STOREHUBX_TOKEN or stdin when omitted")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: storehubx login [flags]")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	tok := strings.TrimSpace(firstNonEmpty(*token, os.Getenv("STOREHUBX_TOKEN")))
+	if tok == "" {
+		if isTerminal(os.Stdin) {
+			fmt.Fprintf(os.Stderr, "Sign in at %s/auth/github/login.\n", strings.TrimRight(*api, "/"))
+			fmt.Fprint(os.Stderr, "When the dashboard opens, copy the token= value from its address bar and paste it here: ")
+		}
+		line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		tok = strings.TrimSpace(line)
+	}
+	if tok == "" {
+		return errors.New("no token given")
+	}
+
+	c := newClient(*api)
+	c.token = tok
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	var me struct {
+		User struct {
+			Username string `json:"username"`
+		} `json:"user"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/me", nil, &me); err != nil {
+		return fmt.Errorf("token rejected: %w", err)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	cfg.API, cfg.Token, cfg.Username = c.base, tok, me.User.Username
+	p, err := cfg.save()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Logged in to %s as %s (saved to %s)\n", c.base, firstNonEmpty(me.User.Username, "unknown user"), p)
+	return nil
+}
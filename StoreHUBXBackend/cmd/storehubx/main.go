@@ -0,0 +1,95 @@
+// Command storehubx installs StoreHUBX components into a project and
+// publishes them from a component's repository.
+//
+//	storehubx install <slug>[@version|@range|@tag] ...   add or update components
+//	storehubx install                                    restore everything in storehubx-lock.json
+//	storehubx remove <slug> ...                          delete installed files
+//	storehubx login                                      store an API token
+//	storehubx init                                       write storehubx.json for the current folder
+//	storehubx link                                       create the component and link it to the git remote
+//	storehubx publish                                    release HEAD and wait for its build
+//
+// The API defaults to $STOREHUBX_API, then the API saved by login, then
+// http://localhost:8080. $STOREHUBX_TOKEN overrides the saved token.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch cmd, args := os.Args[1], os.Args[2:]; cmd {
+	case "install", "add", "i":
+		err = runInstall(args)
+	case "remove", "rm", "uninstall":
+		err = runRemove(args)
+	case "login":
+		err = runLogin(args)
+	case "init":
+		err = runInit(args)
+	case "link":
+		err = runLink(args)
+	case "publish":
+		err = runPublish(args)
+	case "help", "-h", "--help":
+		usage()
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "storehubx: unknown command %q\n\n", cmd)
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "storehubx:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprint(os.Stderr, `Usage: storehubx <command> [flags] [args]
+
+Commands:
+  install, add   install components: storehubx install button@^1.2 @acme/card@next
+                 with no arguments, restore the versions pinned in storehubx-lock.json
+  remove         remove installed components and their lockfile entries
+
+  login          store an API token: storehubx login --token $TOKEN
+  init           write storehubx.json describing the component in this folder
+  link           create the component and link it to this folder's GitHub repository
+  publish        create a version from HEAD and follow its build until it finishes
+
+Run "storehubx <command> -h" for the flags of a command.
+`)
+}
+
+// apiFlag registers the --api flag shared by every command.
+func apiFlag(flags *flag.FlagSet) *string {
+	def := os.Getenv("STOREHUBX_API")
+	if def == "" {
+		if cfg, err := loadConfig(); err == nil {
+			def = cfg.API
+		}
+	}
+	if def == "" {
+		def = "http://localhost:8080"
+	}
+	return flags.String("api", def, "StoreHUBX API base URL")
+}
+
+// splitSpec splits "button@^1.2" or "@acme/button@1.0.0" into slug and
+// version reference; the reference defaults to "latest".
+func splitSpec(spec string) (slug, ref string) {
+	if i := strings.LastIndex(spec, "@"); i > 0 {
+		return spec[:i], spec[i+1:]
+	}
+	return spec, "latest"
+}
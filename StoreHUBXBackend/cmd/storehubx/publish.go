@@ -0,0 +1,375 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rishyym0927/storehubx/internal/buildlog"
+	"github.com/rishyym0927/storehubx/internal/models"
+)
+
+const manifestName = "storehubx.json"
+
+// manifest describes the component that lives in the folder holding it.
+type manifest struct {
+	Name        string   `json:"name"`
+	Slug        string   `json:"slug,omitempty"` // filled in by "storehubx link" once the component exists
+	Description string   `json:"description,omitempty"`
+	Frameworks  []string `json:"frameworks"`
+	Tags        []string `json:"tags,omitempty"`
+	License     string   `json:"license,omitempty"`
+	Scoped      bool     `json:"scoped,omitempty"`  // publish as @username/name
+	Version     string   `json:"version,omitempty"` // version "publish" creates; empty bumps the patch
+}
+
+func readManifest() (*manifest, error) {
+	b, err := os.ReadFile(manifestName)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf(`no %s here: run "storehubx init" in the component folder first`, manifestName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("%s: %w", manifestName, err)
+	}
+	return &m, nil
+}
+
+func (m *manifest) write() error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(manifestName, append(b, '\n'), 0o644)
+}
+
+func runInit(args []string) error {
+	flags := flag.NewFlagSet("init", flag.ExitOnError)
+	name := flags.String("name", "", "component name (default: package.json name or folder name)")
+	description := flags.String("description", "", "short description")
+	frameworks := flags.String("frameworks", "", "comma-separated frameworks (default: detected from package.json)")
+	tags := flags.String("tags", "", "comma-separated tags")
+	license := flags.String("license", "", `license (default: package.json license or "MIT")`)
+	scoped := flags.Bool("scoped", false, "publish under your username as @username/name")
+	yes := flags.Bool("yes", false, "accept defaults without prompting")
+	force := flags.Bool("force", false, "overwrite an existing "+manifestName)
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: storehubx init [flags]")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if _, err := os.Stat(manifestName); err == nil && !*force {
+		return fmt.Errorf("%s already exists (use --force to overwrite)", manifestName)
+	}
+
+	pkg := readPackageJSON()
+	cwd, _ := os.Getwd()
+	m := &manifest{
+		Name:        firstNonEmpty(*name, pkg.name(), filepath.Base(cwd)),
+		Description: firstNonEmpty(*description, pkg.Description),
+		Frameworks:  splitList(firstNonEmpty(*frameworks, strings.Join(pkg.frameworks(), ","))),
+		Tags:        splitList(*tags),
+		License:     firstNonEmpty(*license, pkg.License, "MIT"),
+		Scoped:      *scoped,
+	}
+
+	if !*yes && isTerminal(os.Stdin) {
+		in := bufio.NewReader(os.Stdin)
+		m.Name = ask(in, "Name", m.Name)
+		m.Description = ask(in, "Description", m.Description)
+		m.Frameworks = splitList(ask(in, "Frameworks", strings.Join(m.Frameworks, ",")))
+		m.Tags = splitList(ask(in, "Tags", strings.Join(m.Tags, ",")))
+		m.License = ask(in, "License", m.License)
+	}
+	if m.Name == "" || len(m.Frameworks) == 0 {
+		return errors.New("a name and at least one framework are required")
+	}
+
+	if err := m.write(); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s. Commit it, then run \"storehubx link\".\n", manifestName)
+	return nil
+}
+
+func runLink(args []string) error {
+	flags := flag.NewFlagSet("link", flag.ExitOnError)
+	api := apiFlag(flags)
+	remote := flags.String("remote", "origin", "git remote pointing at the GitHub repository")
+	ref := flags.String("ref", "", "branch builds follow (default: current branch)")
+	commit := flags.String("commit", "", "commit to pin (default: HEAD)")
+	tagReleases := flags.Bool("tag-releases", false, "create versions from semver tags and GitHub releases instead of pushes")
+	tagPrefix := flags.String("tag-prefix", "", `tag prefix selecting this component's tags in a monorepo, e.g. "button@"`)
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: storehubx link [flags]   (run in the component folder)")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	m, err := readManifest()
+	if err != nil {
+		return err
+	}
+	c, err := authClient(*api)
+	if err != nil {
+		return err
+	}
+
+	remoteURL, err := git("remote", "get-url", *remote)
+	if err != nil {
+		return err
+	}
+	owner, repo, err := parseGitHubRemote(remoteURL)
+	if err != nil {
+		return err
+	}
+	prefix, err := git("rev-parse", "--show-prefix")
+	if err != nil {
+		return err
+	}
+	if *ref == "" {
+		if *ref, err = currentRef(); err != nil {
+			return err
+		}
+	}
+	if *commit == "" {
+		if *commit, err = git("rev-parse", "HEAD"); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if m.Slug == "" {
+		var created struct {
+			Component models.Component `json:"component"`
+		}
+		if err := c.do(ctx, http.MethodPost, "/api/components", map[string]any{
+			"name":        m.Name,
+			"description": m.Description,
+			"frameworks":  m.Frameworks,
+			"tags":        m.Tags,
+			"license":     m.License,
+			"scoped":      m.Scoped,
+		}, &created); err != nil {
+			return fmt.Errorf("create component: %w", err)
+		}
+		m.Slug = created.Component.Slug
+		if err := m.write(); err != nil {
+			return err
+		}
+		fmt.Printf("Created component %s\n", m.Slug)
+	}
+
+	var linked struct {
+		InitialVersion *models.ComponentVersion `json:"initialVersion"`
+		Message        string                   `json:"message"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/api/components/"+url.PathEscape(m.Slug)+"/link", map[string]any{
+		"owner":       owner,
+		"repo":        repo,
+		"path":        strings.TrimSuffix(prefix, "/"),
+		"ref":         *ref,
+		"commit":      *commit,
+		"tagReleases": *tagReleases,
+		"tagPrefix":   *tagPrefix,
+	}, &linked); err != nil {
+		return fmt.Errorf("link %s: %w", m.Slug, err)
+	}
+
+	fmt.Printf("Linked %s to %s/%s/%s on %s at %s\n", m.Slug, owner, repo, strings.TrimSuffix(prefix, "/"), *ref, shortSHA(*commit))
+	if linked.InitialVersion != nil {
+		fmt.Printf("Version %s created and queued for build\n", linked.InitialVersion.Version)
+	}
+	return nil
+}
+
+func runPublish(args []string) error {
+	flags := flag.NewFlagSet("publish", flag.ExitOnError)
+	api := apiFlag(flags)
+	version := flags.String("version", "", "version to create (default: manifest version, else next patch)")
+	changelog := flags.String("changelog", "", "changelog for the new version")
+	commit := flags.String("commit", "", "commit to publish (default: HEAD)")
+	noWait := flags.Bool("no-wait", false, "return once the build is queued")
+	timeout := flags.Duration("timeout", 30*time.Minute, "how long to wait for the build")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: storehubx publish [flags]   (run in the component folder)")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	m, err := readManifest()
+	if err != nil {
+		return err
+	}
+	if m.Slug == "" {
+		return errors.New(`component is not linked yet: run "storehubx link" first`)
+	}
+	c, err := authClient(*api)
+	if err != nil {
+		return err
+	}
+	if *commit == "" {
+		if *commit, err = git("rev-parse", "HEAD"); err != nil {
+			return err
+		}
+	}
+	warnUnpublished(*commit)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	var deployed struct {
+		Version models.ComponentVersion `json:"version"`
+		JobID   string                  `json:"jobId"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/api/components/"+url.PathEscape(m.Slug)+"/deploy", map[string]any{
+		"commitSha": *commit,
+		"version":   firstNonEmpty(*version, m.Version),
+		"changelog": *changelog,
+	}, &deployed); err != nil {
+		return fmt.Errorf("publish %s: %w", m.Slug, err)
+	}
+	fmt.Printf("Publishing %s@%s from %s (build %s)\n", m.Slug, deployed.Version.Version, shortSHA(*commit), deployed.JobID)
+	if *noWait {
+		return nil
+	}
+	return tailBuild(ctx, c, deployed.JobID)
+}
+
+// tailBuild prints the build log as it grows and fails unless the build
+// succeeds.
+func tailBuild(ctx context.Context, c *client, jobID string) error {
+	var page struct {
+		Lines  []buildlog.Line    `json:"lines"`
+		Next   int                `json:"next"`
+		Status models.BuildStatus `json:"status"`
+		Done   bool               `json:"done"`
+	}
+	for {
+		page.Lines = nil
+		path := fmt.Sprintf("/api/builds/%s/logs?after=%d&limit=1000", jobID, page.Next)
+		if err := c.do(ctx, http.MethodGet, path, nil, &page); err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("gave up waiting for build %s", jobID)
+			}
+			return err
+		}
+		for _, l := range page.Lines {
+			fmt.Println(l.String())
+		}
+		if page.Done {
+			break
+		}
+		if len(page.Lines) == 0 {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("gave up waiting for build %s (status %s)", jobID, page.Status)
+			case <-time.After(time.Second):
+			}
+		}
+	}
+
+	var res struct {
+		Build models.BuildJob `json:"build"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/builds/"+jobID, nil, &res); err != nil {
+		return err
+	}
+	if page.Status != models.BuildSuccess {
+		msg := fmt.Sprintf("build %s finished with status %s", jobID, page.Status)
+		if a := res.Build.Artifacts; a != nil && a.LogURL != "" {
+			msg += "; full log: " + a.LogURL
+		}
+		return errors.New(msg)
+	}
+	if a := res.Build.Artifacts; a != nil && a.BundleURL != "" {
+		fmt.Printf("Published %s@%s: %s\n", res.Build.Component, res.Build.Version, a.BundleURL)
+	} else {
+		fmt.Printf("Published %s@%s\n", res.Build.Component, res.Build.Version)
+	}
+	return nil
+}
+
+// packageJSON holds the package.json fields init uses for defaults.
+type packageJSON struct {
+	Name            string            `json:"name"`
+	Description     string            `json:"description"`
+	License         string            `json:"license"`
+	Dependencies    map[string]string `json:"dependencies"`
+	DevDependencies map[string]string `json:"devDependencies"`
+	PeerDeps        map[string]string `json:"peerDependencies"`
+}
+
+func readPackageJSON() *packageJSON {
+	var pkg packageJSON
+	if b, err := os.ReadFile("package.json"); err == nil {
+		_ = json.Unmarshal(b, &pkg)
+	}
+	return &pkg
+}
+
+// name drops an npm scope: "@acme/button" -> "button".
+func (p *packageJSON) name() string {
+	return p.Name[strings.LastIndex(p.Name, "/")+1:]
+}
+
+func (p *packageJSON) frameworks() []string {
+	known := []struct{ dep, framework string }{
+		{"react", "react"},
+		{"vue", "vue"},
+		{"svelte", "svelte"},
+		{"@angular/core", "angular"},
+		{"solid-js", "solid"},
+	}
+	var out []string
+	for _, k := range known {
+		for _, deps := range []map[string]string{p.Dependencies, p.PeerDeps, p.DevDependencies} {
+			if _, ok := deps[k.dep]; ok {
+				out = append(out, k.framework)
+				break
+			}
+		}
+	}
+	if len(out) == 0 {
+		out = []string{"html"}
+	}
+	return out
+}
+
+func ask(in *bufio.Reader, label, def string) string {
+	if def != "" {
+		fmt.Fprintf(os.Stderr, "%s (%s): ", label, def)
+	} else {
+		fmt.Fprintf(os.Stderr, "%s: ", label)
+	}
+	line, _ := in.ReadString('\n')
+	if line = strings.TrimSpace(line); line != "" {
+		return line
+	}
+	return def
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -19,9 +19,9 @@ func main() {
 	db.Init(config.AppConfig.MongoURI)
 	defer db.Disconnect()
 
-	uploader, err := storage.NewS3Uploader()
+	uploader, err := storage.NewFromEnv()
 	if err != nil {
-		log.Fatal("s3 uploader:", err)
+		log.Fatal("storage:", err)
 	}
 
 	proc := worker.NewProcessor(uploader)
@@ -29,6 +29,7 @@ func main() {
 	defer cancel()
 
 	log.Println("storehub-worker: running")
+	// Run returns after SIGINT/SIGTERM once in-flight builds finished or were requeued
 	proc.Run(ctx)
 	log.Println("storehub-worker: stopped")
 }
@@ -0,0 +1,166 @@
+// Package artifact packs a component's source folder into the installable
+// tarball stored next to every version's preview, plus the manifest that
+// describes it.
+//
+// Layout in the bucket:
+//
+//	components/<slug>/<version>/_source/source.tgz
+//	components/<slug>/<version>/_source/manifest.json
+package artifact
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+const (
+	TarballName  = "source.tgz"
+	ManifestName = "manifest.json"
+)
+
+var ErrTooLarge = errors.New("source exceeds size limit")
+
+// Directories never shipped to installers.
+var skipDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+}
+
+type File struct {
+	Path   string `json:"path"`   // slash-separated, relative to the component folder
+	Size   int64  `json:"size"`   // bytes
+	SHA256 string `json:"sha256"` // hex digest of the contents
+}
+
+type Manifest struct {
+	Component string    `json:"component"`
+	Version   string    `json:"version"`
+	Commit    string    `json:"commit,omitempty"`
+	Integrity string    `json:"integrity"` // "sha256-<base64>" of the tarball, as in npm's lockfile
+	Size      int64     `json:"size"`      // tarball bytes
+	Files     []File    `json:"files"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Prefix is the bucket folder holding a version's source artifact.
+func Prefix(slug, version string) string {
+	return path.Join("components", slug, version, "_source") + "/"
+}
+
+func TarballKey(slug, version string) string  { return Prefix(slug, version) + TarballName }
+func ManifestKey(slug, version string) string { return Prefix(slug, version) + ManifestName }
+
+// Integrity formats a SHA-256 digest as a Subresource Integrity string.
+func Integrity(sum []byte) string {
+	return "sha256-" + base64.StdEncoding.EncodeToString(sum)
+}
+
+// Pack writes dir as a gzipped tarball to w and returns its manifest (the
+// caller fills in component, version and commit). Entries are sorted and
+// carry no timestamps or owners, so the same tree always produces the same
+// bytes and integrity. limit caps the total uncompressed size (0 = none).
+func Pack(dir string, w io.Writer, limit int64) (*Manifest, error) {
+	sum := sha256.New()
+	cw := &countWriter{w: io.MultiWriter(w, sum)}
+	gz := gzip.NewWriter(cw)
+	tw := tar.NewWriter(gz)
+
+	m := &Manifest{Files: []File{}}
+	var total int64
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if d.IsDir() {
+			if p != dir && skipDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil // symlinks and devices are not part of a component
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		total += info.Size()
+		if limit > 0 && total > limit {
+			return fmt.Errorf("%w of %d bytes", ErrTooLarge, limit)
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		mode := int64(0o644)
+		if info.Mode()&0o111 != 0 {
+			mode = 0o755
+		}
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Size:     info.Size(),
+			Mode:     mode,
+			Format:   tar.FormatPAX,
+		}); err != nil {
+			return err
+		}
+		digest, err := copyFile(tw, p)
+		if err != nil {
+			return err
+		}
+		m.Files = append(m.Files, File{Path: name, Size: info.Size(), SHA256: hex.EncodeToString(digest.Sum(nil))})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+
+	m.Integrity = Integrity(sum.Sum(nil))
+	m.Size = cw.n
+	return m, nil
+}
+
+func copyFile(w io.Writer, p string) (hash.Hash, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(w, h), f); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,86 @@
+package artifact
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+var ErrIntegrity = errors.New("integrity check failed")
+
+// maxTarball bounds what Unpack reads into memory.
+const maxTarball = 512 << 20
+
+// Entry is one file of an unpacked source tarball.
+type Entry struct {
+	Path string // slash-separated, relative and free of ".."
+	Mode fs.FileMode
+	Data []byte
+}
+
+// CheckIntegrity verifies data against a "sha256-<base64>" integrity string.
+func CheckIntegrity(data []byte, integrity string) error {
+	if !strings.HasPrefix(integrity, "sha256-") {
+		return fmt.Errorf("%w: unsupported integrity %q", ErrIntegrity, integrity)
+	}
+	sum := sha256.Sum256(data)
+	if got := Integrity(sum[:]); got != integrity {
+		return fmt.Errorf("%w: expected %s, got %s", ErrIntegrity, integrity, got)
+	}
+	return nil
+}
+
+// Unpack reads a source tarball, checks it against integrity and returns its
+// files. Entries that would land outside the install folder are rejected.
+func Unpack(r io.Reader, integrity string) ([]Entry, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxTarball+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxTarball {
+		return nil, fmt.Errorf("tarball larger than %d bytes", maxTarball)
+	}
+	if err := CheckIntegrity(data, integrity); err != nil {
+		return nil, err
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gz)
+	var entries []Entry
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean(h.Name)
+		if path.IsAbs(name) || name == "." || name == ".." || strings.HasPrefix(name, "../") || strings.Contains(name, "\\") {
+			return nil, fmt.Errorf("unsafe path %q in tarball", h.Name)
+		}
+		body, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		mode := fs.FileMode(h.Mode).Perm()
+		if mode == 0 {
+			mode = 0o644
+		}
+		entries = append(entries, Entry{Path: name, Mode: mode, Data: body})
+	}
+	return entries, nil
+}
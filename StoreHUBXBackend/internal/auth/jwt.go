@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -23,3 +24,44 @@ func VerifyJWT(tokenString string) (*jwt.Token, error) {
 		return []byte(config.AppConfig.JWTSecret), nil
 	})
 }
+
+// StreamTokenTTL is how long a build log stream token can open a stream.
+const StreamTokenTTL = 10 * time.Minute
+
+// streamPurpose marks stream tokens; JWTProtected rejects tokens that carry
+// a purpose, so a stream token never works as a session token.
+const streamPurpose = "build-log-stream"
+
+// GenerateStreamToken returns a short-lived token that only opens the log
+// stream of one build. Browsers' EventSource cannot send an Authorization
+// header, so the dashboard passes it as ?token= instead of the session JWT.
+func GenerateStreamToken(userID, email, buildID string) (string, time.Time, error) {
+	exp := time.Now().Add(StreamTokenTTL)
+	claims := jwt.MapClaims{
+		"user_id":  userID,
+		"email":    email,
+		"build_id": buildID,
+		"purpose":  streamPurpose,
+		"exp":      exp.Unix(),
+		"iat":      time.Now().Unix(),
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString([]byte(config.AppConfig.JWTSecret))
+	return signed, exp, err
+}
+
+// VerifyStreamToken checks a stream token and that it was issued for buildID.
+func VerifyStreamToken(tokenString, buildID string) (jwt.MapClaims, error) {
+	token, err := VerifyJWT(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid || claims["purpose"] != streamPurpose {
+		return nil, errors.New("not a stream token")
+	}
+	if claims["build_id"] != buildID {
+		return nil, errors.New("stream token is for another build")
+	}
+	return claims, nil
+}
@@ -0,0 +1,27 @@
+package buildlog
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NewMemoryStore returns a Store that keeps lines in memory instead of the
+// build_logs collection, for running the worker without a database.
+func NewMemoryStore() *Store {
+	s := NewStore(nil)
+	s.mem = map[primitive.ObjectID][]Line{}
+	return s
+}
+
+func (s *Store) memInsert(jobID primitive.ObjectID, docs []interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range docs {
+		s.mem[jobID] = append(s.mem[jobID], d.(Line))
+	}
+}
+
+func (s *Store) memLines(jobID primitive.ObjectID) []Line {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Line(nil), s.mem[jobID]...)
+}
@@ -0,0 +1,338 @@
+// Package buildlog stores build output as individual line records in the
+// build_logs collection instead of an ever-growing array on the job document.
+package buildlog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Stream string
+
+const (
+	System Stream = "system" // messages written by the API and the worker itself
+	Stdout Stream = "stdout"
+	Stderr Stream = "stderr"
+)
+
+// MaxLineLength caps a single record; longer output is split.
+const MaxLineLength = 4096
+
+type Line struct {
+	JobID  primitive.ObjectID `bson:"jobId" json:"-"`
+	Seq    int                `bson:"seq" json:"seq"` // 1-based, contiguous per job
+	Time   time.Time          `bson:"ts" json:"ts"`
+	Stream Stream             `bson:"stream" json:"stream"`
+	Step   string             `bson:"step,omitempty" json:"step,omitempty"`
+	Text   string             `bson:"line" json:"text"`
+}
+
+// String renders the line the way it appears in the downloadable log.
+func (l Line) String() string {
+	var b strings.Builder
+	if !l.Time.IsZero() {
+		b.WriteString(l.Time.UTC().Format("2006-01-02T15:04:05.000Z "))
+	}
+	if l.Stream != System && l.Stream != "" {
+		b.WriteString("[" + string(l.Stream) + "] ")
+	}
+	if l.Step != "" {
+		b.WriteString("(" + l.Step + ") ")
+	}
+	b.WriteString(l.Text)
+	return b.String()
+}
+
+// Store appends and reads build log lines. Sequence numbers are handed out in
+// memory per job and seeded from the collection, so one Store should be shared
+// by everything in a process that writes to the same job.
+type Store struct {
+	col      *mongo.Collection
+	maxLines int // stdout/stderr lines kept per job; system lines are always kept
+
+	mu   sync.Mutex
+	jobs map[primitive.ObjectID]*jobState
+	mem  map[primitive.ObjectID][]Line // lines of a memory store (col is nil)
+}
+
+type jobState struct {
+	mu        sync.Mutex
+	next      int
+	output    int // stdout/stderr lines stored, counted against maxLines
+	truncated bool
+}
+
+// NewStore returns a Store writing to col. BUILD_LOG_MAX_LINES bounds how much
+// command output one job may store (default 20000).
+func NewStore(col *mongo.Collection) *Store {
+	maxLines, _ := strconv.Atoi(os.Getenv("BUILD_LOG_MAX_LINES"))
+	if maxLines <= 0 {
+		maxLines = 20000
+	}
+	return &Store{col: col, maxLines: maxLines, jobs: map[primitive.ObjectID]*jobState{}}
+}
+
+// EnsureIndexes creates the (jobId, seq) index and the retention TTL index.
+// BUILD_LOG_RETENTION_DAYS sets how long lines are kept (default 30); changing
+// it later updates the existing TTL index in place.
+func EnsureIndexes(ctx context.Context, col *mongo.Collection) error {
+	days, _ := strconv.Atoi(os.Getenv("BUILD_LOG_RETENTION_DAYS"))
+	if days <= 0 {
+		days = 30
+	}
+	ttl := int32(days * 24 * 60 * 60)
+
+	if _, err := col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "jobId", Value: 1}, {Key: "seq", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("job_seq"),
+	}); err != nil {
+		return err
+	}
+	_, err := col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "ts", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(ttl).SetName("ts_ttl"),
+	})
+	if err != nil {
+		// index exists with a different expiry
+		err = col.Database().RunCommand(ctx, bson.D{
+			{Key: "collMod", Value: col.Name()},
+			{Key: "index", Value: bson.M{"name": "ts_ttl", "expireAfterSeconds": ttl}},
+		}).Err()
+	}
+	return err
+}
+
+// Append stores text as one or more lines (it is split on newlines).
+func (s *Store) Append(ctx context.Context, jobID primitive.ObjectID, stream Stream, step, text string) error {
+	texts := SplitLines(text)
+	if len(texts) == 0 {
+		return nil
+	}
+
+	st := s.state(jobID)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.next == 0 {
+		if err := s.seed(ctx, jobID, st); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	docs := make([]interface{}, 0, len(texts)+1)
+	output := 0 // stdout/stderr lines in docs
+	for _, t := range texts {
+		if stream != System && st.output+output >= s.maxLines {
+			if !st.truncated {
+				st.truncated = true
+				docs = append(docs, Line{Stream: System, Step: step,
+					Text: fmt.Sprintf("[log truncated: output beyond %d lines is not stored]", s.maxLines)})
+			}
+			continue
+		}
+		docs = append(docs, Line{Stream: stream, Step: step, Text: t})
+		if stream != System {
+			output++
+		}
+	}
+	if len(docs) == 0 {
+		return nil
+	}
+
+	for attempt := 0; ; attempt++ {
+		for i := range docs {
+			l := docs[i].(Line)
+			l.JobID, l.Seq, l.Time = jobID, st.next+i, now
+			docs[i] = l
+		}
+		if s.col == nil {
+			s.memInsert(jobID, docs)
+			st.next += len(docs)
+			st.output += output
+			return nil
+		}
+		_, err := s.col.InsertMany(ctx, docs)
+		if err == nil {
+			st.next += len(docs)
+			st.output += output
+			return nil
+		}
+		// another process wrote to this job meanwhile; re-seed and try again
+		if !mongo.IsDuplicateKeyError(err) || attempt == 2 {
+			return err
+		}
+		if serr := s.seed(ctx, jobID, st); serr != nil {
+			return err
+		}
+	}
+}
+
+// seed reads the job's next sequence number and how much command output it
+// already stored, for jobs this Store has not written to yet.
+func (s *Store) seed(ctx context.Context, jobID primitive.ObjectID, st *jobState) error {
+	last, err := s.LastSeq(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	st.next = last + 1
+	if s.col == nil {
+		st.output = 0
+		for _, l := range s.memLines(jobID) {
+			if l.Stream != System {
+				st.output++
+			}
+		}
+		return nil
+	}
+	n, err := s.col.CountDocuments(ctx, bson.M{"jobId": jobID, "stream": bson.M{"$ne": System}})
+	if err != nil {
+		return err
+	}
+	st.output = int(n)
+	return nil
+}
+
+// DeleteJobs removes every line of the given jobs.
+func (s *Store) DeleteJobs(ctx context.Context, jobIDs []primitive.ObjectID) (int64, error) {
+	if len(jobIDs) == 0 {
+		return 0, nil
+	}
+	if s.col == nil {
+		var n int64
+		s.mu.Lock()
+		for _, id := range jobIDs {
+			n += int64(len(s.mem[id]))
+			delete(s.mem, id)
+		}
+		s.mu.Unlock()
+		return n, nil
+	}
+	res, err := s.col.DeleteMany(ctx, bson.M{"jobId": bson.M{"$in": jobIDs}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// Forget drops the in-memory state for a finished job.
+func (s *Store) Forget(jobID primitive.ObjectID) {
+	s.mu.Lock()
+	delete(s.jobs, jobID)
+	s.mu.Unlock()
+}
+
+func (s *Store) state(jobID primitive.ObjectID) *jobState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.jobs[jobID]
+	if !ok {
+		st = &jobState{}
+		s.jobs[jobID] = st
+	}
+	return st
+}
+
+// LastSeq returns the highest sequence number stored for the job (0 if none).
+func (s *Store) LastSeq(ctx context.Context, jobID primitive.ObjectID) (int, error) {
+	if s.col == nil {
+		return len(s.memLines(jobID)), nil
+	}
+	var l Line
+	err := s.col.FindOne(ctx, bson.M{"jobId": jobID},
+		options.FindOne().SetSort(bson.D{{Key: "seq", Value: -1}}).SetProjection(bson.M{"seq": 1}),
+	).Decode(&l)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	return l.Seq, err
+}
+
+// Page returns up to limit lines with seq greater than after, in order.
+func (s *Store) Page(ctx context.Context, jobID primitive.ObjectID, after, limit int) ([]Line, error) {
+	if s.col == nil {
+		// seqs are 1-based and contiguous, so line n sits at index n-1
+		lines := s.memLines(jobID)
+		lines = lines[min(max(after, 0), len(lines)):]
+		return lines[:min(limit, len(lines))], nil
+	}
+	cur, err := s.col.Find(ctx,
+		bson.M{"jobId": jobID, "seq": bson.M{"$gt": after}},
+		options.Find().SetSort(bson.D{{Key: "seq", Value: 1}}).SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	lines := make([]Line, 0)
+	if err := cur.All(ctx, &lines); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// WriteTo writes the job's full log to w, one rendered line per row, and
+// returns the number of lines written.
+func (s *Store) WriteTo(ctx context.Context, jobID primitive.ObjectID, w io.Writer) (int, error) {
+	if s.col == nil {
+		lines := s.memLines(jobID)
+		for i, l := range lines {
+			if _, err := io.WriteString(w, l.String()+"\n"); err != nil {
+				return i, err
+			}
+		}
+		return len(lines), nil
+	}
+	cur, err := s.col.Find(ctx, bson.M{"jobId": jobID}, options.Find().SetSort(bson.D{{Key: "seq", Value: 1}}))
+	if err != nil {
+		return 0, err
+	}
+	defer cur.Close(ctx)
+
+	n := 0
+	for cur.Next(ctx) {
+		var l Line
+		if err := cur.Decode(&l); err != nil {
+			return n, err
+		}
+		if _, err := io.WriteString(w, l.String()+"\n"); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, cur.Err()
+}
+
+// SplitLines breaks command output into log lines: it splits on newlines,
+// drops trailing carriage returns and empty lines, replaces invalid UTF-8 and
+// cuts lines longer than MaxLineLength on a rune boundary.
+func SplitLines(text string) []string {
+	var out []string
+	for _, t := range strings.Split(strings.ToValidUTF8(text, "\uFFFD"), "\n") {
+		t = strings.TrimRight(t, "\r")
+		if strings.TrimSpace(t) == "" {
+			continue
+		}
+		for len(t) > MaxLineLength {
+			cut := MaxLineLength
+			for cut > 0 && !utf8.RuneStart(t[cut]) {
+				cut--
+			}
+			out = append(out, t[:cut])
+			t = t[cut:]
+		}
+		out = append(out, t)
+	}
+	return out
+}
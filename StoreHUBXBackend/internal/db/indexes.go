@@ -2,29 +2,27 @@ package db
 
 import (
 	"context"
+	"log"
 	"time"
 
+	"github.com/rishyym0927/storehubx/internal/buildlog"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func EnsureIndexes(client *mongo.Client) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	db := client.Database("storehub")
+	db := client.Database(Name)
 
-	// components: slug unique
-	_, _ = db.Collection("components").Indexes().CreateOne(ctx, mongo.IndexModel{
-		Keys:    bson.D{{Key: "slug", Value: 1}},
-		Options: nil,
-	})
-
-	// component_versions: componentId + version unique
-	_, _ = db.Collection("component_versions").Indexes().CreateOne(ctx, mongo.IndexModel{
-		Keys:    bson.D{{Key: "componentId", Value: 1}, {Key: "version", Value: 1}},
-		Options: nil,
-	})
+	// components: slug unique, replacing the plain "slug_1" index of older
+	// deployments. component_versions: componentId + version unique, replacing
+	// "componentId_1_version_1". See replaceWithUnique.
+	replaceWithUnique(ctx, db.Collection("components"), "slug_1", "slug", bson.D{{Key: "slug", Value: 1}}, "slug_unique")
+	replaceWithUnique(ctx, db.Collection("component_versions"), "componentId_1_version_1", "version",
+		bson.D{{Key: "componentId", Value: 1}, {Key: "version", Value: 1}}, "component_version_unique")
 
 	// component_versions: componentId + commitSha unique (prevent duplicate commits)
 	_, _ = db.Collection("component_versions").Indexes().CreateOne(ctx, mongo.IndexModel{
@@ -37,7 +35,75 @@ func EnsureIndexes(client *mongo.Client) error {
 		{Keys: bson.D{{Key: "component", Value: 1}}},
 		{Keys: bson.D{{Key: "version", Value: 1}}},
 		{Keys: bson.D{{Key: "status", Value: 1}}},
+		// queue: oldest queued job first, and the reaper's expired-lease scan
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "createdAt", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "leaseExpiresAt", Value: 1}}},
+		// retry chains
+		{Keys: bson.D{{Key: "parentJobId", Value: 1}}, Options: options.Index().SetSparse(true)},
+		// pull request previews
+		{Keys: bson.D{{Key: "componentId", Value: 1}, {Key: "pullRequest.number", Value: 1}}, Options: options.Index().SetSparse(true)},
 	})
 
+	// build_logs: per-job sequence and retention
+	if err := buildlog.EnsureIndexes(ctx, db.Collection("build_logs")); err != nil {
+		log.Printf("⚠️  could not create build_logs indexes: %v", err)
+	}
+
 	return nil
 }
+
+// replaceWithUnique creates a unique index named name on keys. An older
+// non-unique index on the same keys blocks that, so it is dropped first, but
+// only when no duplicates exist; otherwise it is kept and a warning logged,
+// so a failed migration never leaves the collection without either index.
+func replaceWithUnique(ctx context.Context, col *mongo.Collection, oldName, what string, keys bson.D, name string) {
+	unique := mongo.IndexModel{Keys: keys, Options: options.Index().SetUnique(true).SetName(name)}
+	_, err := col.Indexes().CreateOne(ctx, unique)
+	if err == nil {
+		return
+	}
+	fields := make([]string, len(keys))
+	for i, k := range keys {
+		fields[i] = k.Key
+	}
+	dups, derr := countDuplicates(ctx, col, fields...)
+	switch {
+	case derr != nil:
+		log.Printf("⚠️  could not create unique %s index: %v (duplicate check failed: %v)", what, err, derr)
+	case dups > 0:
+		log.Printf("⚠️  could not create unique %s index: %d %s values exist more than once in %s; dedupe them and restart", what, dups, what, col.Name())
+	default:
+		_, _ = col.Indexes().DropOne(ctx, oldName)
+		if _, err := col.Indexes().CreateOne(ctx, unique); err != nil {
+			log.Printf("⚠️  could not create unique %s index: %v", what, err)
+			_, _ = col.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: keys})
+		}
+	}
+}
+
+// countDuplicates returns how many distinct combinations of keys occur in more
+// than one document of col.
+func countDuplicates(ctx context.Context, col *mongo.Collection, keys ...string) (int, error) {
+	group := bson.M{}
+	for _, k := range keys {
+		group[k] = "$" + k
+	}
+	cur, err := col.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": group, "n": bson.M{"$sum": 1}}}},
+		{{Key: "$match", Value: bson.M{"n": bson.M{"$gt": 1}}}},
+		{{Key: "$count", Value: "dups"}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	var out []struct {
+		Dups int `bson:"dups"`
+	}
+	if err := cur.All(ctx, &out); err != nil {
+		return 0, err
+	}
+	if len(out) == 0 {
+		return 0, nil
+	}
+	return out[0].Dups, nil
+}
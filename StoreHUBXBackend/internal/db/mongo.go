@@ -11,6 +11,10 @@ import (
 
 var Client *mongo.Client
 
+// Name is the database the API keeps its data in. Anything that acts on what
+// the API references, like garbage collection, must read this one.
+const Name = "storehub"
+
 // Init initializes Mongo connection
 func Init(uri string) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -0,0 +1,388 @@
+// Package gc deletes stored artifacts nothing refers to any more: files of
+// deleted components and versions, previews of pull requests whose builds
+// are gone, partial uploads of builds that never succeeded and logs of
+// deleted jobs.
+//
+// Layout it understands:
+//
+//	components/<slug>/<version>/...           preview (slug may be "@scope/name")
+//	components/<slug>/<version>/_source/...   installable source snapshot
+//	components/<slug>/pr-<n>/...              pull request preview
+//	build-logs/<jobId>.log                    archived build log
+//
+// Anything else in the store is left alone.
+package gc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rishyym0927/storehubx/internal/models"
+	"github.com/rishyym0927/storehubx/internal/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DefaultGrace is how long unreferenced objects are kept unless Options
+// says otherwise. It covers uploads of builds that are still being
+// recorded.
+const DefaultGrace = 24 * time.Hour
+
+// ErrNoComponents is returned instead of collecting when the database has
+// no components but storage has component files, which almost always means
+// the collector is pointed at the wrong database.
+var ErrNoComponents = errors.New("no components in the database but component files in storage; refusing to collect")
+
+// Options controls one collection.
+type Options struct {
+	Grace     time.Duration // unreferenced objects modified more recently are kept; 0 means DefaultGrace
+	Component string        // only look at this slug; build logs are then skipped
+	Version   string        // with Component, only look at this version
+	DryRun    bool          // report without deleting
+}
+
+// Artifact is a group of unreferenced objects, deleted together.
+type Artifact struct {
+	Prefix       string    `json:"prefix"`           // key prefix, or an object key when Single is set
+	Single       bool      `json:"single,omitempty"` // Prefix is one object's key
+	Reason       string    `json:"reason"`
+	Objects      int       `json:"objects"`
+	Bytes        int64     `json:"bytes"`
+	LastModified time.Time `json:"lastModified"` // newest object in the group
+	Deleted      bool      `json:"deleted"`
+	Error        string    `json:"error,omitempty"` // set when deleting failed
+}
+
+// Result is what a collection found and did.
+type Result struct {
+	Scanned      int        `json:"scannedObjects"`
+	ScannedBytes int64      `json:"scannedBytes"`
+	Collected    []Artifact `json:"collected"`        // unreferenced and older than the grace period
+	Recent       []Artifact `json:"recent,omitempty"` // unreferenced but inside the grace period
+	Reclaimed    int64      `json:"reclaimedBytes"`   // bytes deleted, or that would be in a dry run
+}
+
+// Collector cross-references storage with the components, component_versions
+// and build_jobs collections of one database.
+type Collector struct {
+	db    *mongo.Database
+	store storage.Uploader
+	now   func() time.Time
+}
+
+// New returns a collector for the given database and store.
+func New(database *mongo.Database, store storage.Uploader) *Collector {
+	return &Collector{db: database, store: store, now: time.Now}
+}
+
+// group is the objects under one prefix.
+type group struct {
+	objects  int
+	bytes    int64
+	modified time.Time
+}
+
+func (g *group) add(obj storage.ObjectInfo) {
+	g.objects++
+	g.bytes += obj.Size
+	if obj.LastModified.After(g.modified) {
+		g.modified = obj.LastModified
+	}
+}
+
+// versionFiles is what storage holds for one version folder.
+type versionFiles struct {
+	all    group
+	source group // the _source/ part of all
+}
+
+// Run lists the store, decides what is unreferenced and, unless opts.DryRun
+// is set, deletes what is older than the grace period. Failed deletions are
+// recorded on their artifact and do not stop the run.
+func (c *Collector) Run(ctx context.Context, opts Options) (*Result, error) {
+	if opts.Grace <= 0 {
+		opts.Grace = DefaultGrace
+	}
+	opts.Component = strings.Trim(opts.Component, "/")
+	if opts.Version != "" && opts.Component == "" {
+		return nil, errors.New("gc: Version needs Component")
+	}
+	res := &Result{Collected: []Artifact{}}
+
+	prefix := "components/"
+	if opts.Component != "" {
+		prefix += opts.Component + "/"
+		if opts.Version != "" {
+			prefix += opts.Version + "/"
+		}
+	}
+	objects, err := c.store.List(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("list %s: %w", prefix, err)
+	}
+	// slug -> version -> files; version "" holds files directly under the slug
+	tree := map[string]map[string]*versionFiles{}
+	for _, obj := range objects {
+		res.Scanned++
+		res.ScannedBytes += obj.Size
+		slug, version, rest, ok := splitComponentKey(obj.Key)
+		if !ok {
+			continue
+		}
+		if tree[slug] == nil {
+			tree[slug] = map[string]*versionFiles{}
+		}
+		vf := tree[slug][version]
+		if vf == nil {
+			vf = &versionFiles{}
+			tree[slug][version] = vf
+		}
+		vf.all.add(obj)
+		if strings.HasPrefix(rest, "_source/") {
+			vf.source.add(obj)
+		}
+	}
+
+	var logs []storage.ObjectInfo
+	if opts.Component == "" {
+		if logs, err = c.store.List(ctx, "build-logs/"); err != nil {
+			return nil, fmt.Errorf("list build-logs/: %w", err)
+		}
+		for _, obj := range logs {
+			res.Scanned++
+			res.ScannedBytes += obj.Size
+		}
+	}
+
+	refs, err := c.loadRefs(ctx, opts.Component)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Component == "" && len(refs.components) == 0 && len(tree) > 0 {
+		return nil, ErrNoComponents
+	}
+
+	var found []Artifact
+	for slug, versions := range tree {
+		compID, ok := refs.components[slug]
+		if !ok {
+			var all group
+			for _, vf := range versions {
+				all.objects += vf.all.objects
+				all.bytes += vf.all.bytes
+				if vf.all.modified.After(all.modified) {
+					all.modified = vf.all.modified
+				}
+			}
+			p := "components/" + slug + "/"
+			if opts.Version != "" {
+				p += opts.Version + "/"
+			}
+			found = append(found, artifact(p, "component no longer exists", all))
+			continue
+		}
+		for version, vf := range versions {
+			if version == "" {
+				continue
+			}
+			if a, ok := refs.check(compID, slug, version, vf); !ok {
+				found = append(found, a)
+			}
+		}
+	}
+	for _, obj := range logs {
+		id, err := primitive.ObjectIDFromHex(strings.TrimSuffix(strings.TrimPrefix(obj.Key, "build-logs/"), ".log"))
+		if err != nil || refs.jobIDs[id] {
+			continue
+		}
+		var g group
+		g.add(obj)
+		a := artifact(obj.Key, "build job no longer exists", g)
+		a.Single = true
+		found = append(found, a)
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].Prefix < found[j].Prefix })
+
+	cutoff := c.now().Add(-opts.Grace)
+	for _, a := range found {
+		if a.LastModified.After(cutoff) {
+			res.Recent = append(res.Recent, a)
+			continue
+		}
+		if !opts.DryRun {
+			if a.Single {
+				err = c.store.Delete(ctx, a.Prefix)
+			} else {
+				_, err = c.store.DeletePrefix(ctx, a.Prefix)
+			}
+			if err != nil {
+				a.Error = err.Error()
+				res.Collected = append(res.Collected, a)
+				continue
+			}
+			a.Deleted = true
+		}
+		res.Reclaimed += a.Bytes
+		res.Collected = append(res.Collected, a)
+	}
+	return res, nil
+}
+
+func artifact(prefix, reason string, g group) Artifact {
+	return Artifact{Prefix: prefix, Reason: reason, Objects: g.objects, Bytes: g.bytes, LastModified: g.modified}
+}
+
+// splitComponentKey splits components/<slug>/<version>/<rest>. Scoped slugs
+// span two segments. Files directly under the slug have an empty version.
+func splitComponentKey(key string) (slug, version, rest string, ok bool) {
+	rel, ok := strings.CutPrefix(key, "components/")
+	if !ok {
+		return "", "", "", false
+	}
+	parts := strings.Split(rel, "/")
+	n := 1
+	if strings.HasPrefix(parts[0], "@") {
+		n = 2
+	}
+	if len(parts) <= n {
+		return "", "", "", false
+	}
+	slug = strings.Join(parts[:n], "/")
+	if len(parts) == n+1 {
+		return slug, "", parts[n], true
+	}
+	return slug, parts[n], strings.Join(parts[n+1:], "/"), true
+}
+
+type versionKey struct {
+	component primitive.ObjectID
+	version   string
+}
+
+// jobSummary is what build_jobs says about one version.
+type jobSummary struct {
+	succeeded bool // some build finished successfully
+	active    bool // a build is queued or running
+}
+
+// refs is everything in the database that keeps a file alive.
+type refs struct {
+	components map[string]primitive.ObjectID // slug -> ID
+	versions   map[versionKey]models.ComponentVersion
+	jobs       map[versionKey]jobSummary // versions with at least one job
+	jobIDs     map[primitive.ObjectID]bool
+}
+
+// loadRefs reads the references of one component, or of all of them when
+// slug is empty.
+func (c *Collector) loadRefs(ctx context.Context, slug string) (*refs, error) {
+	r := &refs{
+		components: map[string]primitive.ObjectID{},
+		versions:   map[versionKey]models.ComponentVersion{},
+		jobs:       map[versionKey]jobSummary{},
+		jobIDs:     map[primitive.ObjectID]bool{},
+	}
+
+	compFilter := bson.M{}
+	if slug != "" {
+		compFilter["slug"] = slug
+	}
+	var comps []models.Component
+	if err := findAll(ctx, c.db.Collection("components"), compFilter, bson.M{"slug": 1}, &comps); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(comps))
+	for _, comp := range comps {
+		r.components[comp.Slug] = comp.ID
+		ids = append(ids, comp.ID)
+	}
+
+	scoped := bson.M{}
+	if slug != "" {
+		scoped["componentId"] = bson.M{"$in": ids}
+	}
+	var versions []models.ComponentVersion
+	if err := findAll(ctx, c.db.Collection("component_versions"), scoped,
+		bson.M{"componentId": 1, "version": 1, "buildState": 1, "previewUrl": 1, "source": 1}, &versions); err != nil {
+		return nil, err
+	}
+	for _, v := range versions {
+		r.versions[versionKey{v.ComponentID, v.Version}] = v
+	}
+
+	var jobs []models.BuildJob
+	if err := findAll(ctx, c.db.Collection("build_jobs"), scoped,
+		bson.M{"componentId": 1, "version": 1, "status": 1}, &jobs); err != nil {
+		return nil, err
+	}
+	for _, j := range jobs {
+		r.jobIDs[j.ID] = true
+		k := versionKey{j.ComponentID, j.Version}
+		s := r.jobs[k]
+		s.succeeded = s.succeeded || j.Status == models.BuildSuccess
+		s.active = s.active || j.Status == models.BuildQueued || j.Status == models.BuildRunning
+		r.jobs[k] = s
+	}
+	return r, nil
+}
+
+// check reports whether a version folder is still referenced, and if not,
+// the artifact to collect. A referenced version can still have an
+// unreferenced _source/ snapshot.
+func (r *refs) check(compID primitive.ObjectID, slug, version string, vf *versionFiles) (Artifact, bool) {
+	prefix := "components/" + slug + "/" + version + "/"
+	k := versionKey{compID, version}
+	jobs, hasJobs := r.jobs[k]
+
+	if n, ok := previewNumber(version); ok {
+		if hasJobs {
+			return Artifact{}, true
+		}
+		return artifact(prefix, fmt.Sprintf("pull request #%d has no preview builds left", n), vf.all), false
+	}
+
+	ver, ok := r.versions[k]
+	if !ok {
+		if jobs.active {
+			return Artifact{}, true
+		}
+		return artifact(prefix, "version no longer exists", vf.all), false
+	}
+	building := jobs.active || ver.BuildState == models.VersionBuildQueued || ver.BuildState == models.VersionBuildRunning
+	// rows from before builds existed have no state and count as published
+	published := ver.BuildState == models.VersionBuildReady || ver.BuildState == "" || ver.PreviewURL != "" || jobs.succeeded
+	if !building && !published {
+		return artifact(prefix, "no build of this version succeeded", vf.all), false
+	}
+	if vf.source.objects > 0 && ver.Source == nil && !building {
+		return artifact(prefix+"_source/", "source snapshot is not referenced by the version", vf.source), false
+	}
+	return Artifact{}, true
+}
+
+// previewNumber parses the pull request number of a "pr-<n>" version.
+func previewNumber(version string) (int, bool) {
+	n, err := strconv.Atoi(strings.TrimPrefix(version, "pr-"))
+	if err != nil || n <= 0 || version != models.PreviewVersion(n) {
+		return 0, false
+	}
+	return n, true
+}
+
+func findAll(ctx context.Context, col *mongo.Collection, filter, projection bson.M, out interface{}) error {
+	cur, err := col.Find(ctx, filter, options.Find().SetProjection(projection))
+	if err != nil {
+		return fmt.Errorf("%s: %w", col.Name(), err)
+	}
+	if err := cur.All(ctx, out); err != nil {
+		return fmt.Errorf("%s: %w", col.Name(), err)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package githubapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/rishyym0927/storehubx/internal/db"
+	"github.com/rishyym0927/storehubx/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const apiBase = "https://api.github.com"
+
+// Client is a minimal GitHub REST client acting on behalf of one user. It is
+// used outside request handlers (webhooks, the worker), where the user's token
+// is looked up by provider ID rather than taken from the request.
+type Client struct {
+	token string
+	http  *http.Client
+}
+
+func NewClient(token string) *Client {
+	return &Client{token: token, http: http.DefaultClient}
+}
+
+// TokenForUser returns the decrypted GitHub token stored for a user (providerId).
+func TokenForUser(ctx context.Context, providerID string) (string, error) {
+	var u struct {
+		AccessToken string `bson:"accessToken"`
+	}
+	col := db.Client.Database("storehub").Collection("users")
+	if err := col.FindOne(ctx, bson.M{"providerId": providerID}).Decode(&u); err != nil {
+		return "", fmt.Errorf("user %s not found: %w", providerID, err)
+	}
+	token := utils.Decrypt(u.AccessToken)
+	if token == "" {
+		return "", fmt.Errorf("no usable token stored for user %s", providerID)
+	}
+	return token, nil
+}
+
+// ResolveCommit returns the commit SHA a branch, tag or SHA points to
+// (annotated tags are peeled to their commit).
+func (gc *Client) ResolveCommit(ctx context.Context, owner, repo, ref string) (string, error) {
+	var commit struct {
+		SHA string `json:"sha"`
+	}
+	path := fmt.Sprintf("/repos/%s/%s/commits/%s", url.PathEscape(owner), url.PathEscape(repo), url.PathEscape(ref))
+	if err := gc.do(ctx, "GET", path, nil, &commit); err != nil {
+		return "", err
+	}
+	return commit.SHA, nil
+}
+
+// do sends a request to the REST API and decodes the JSON response into out
+// (if non-nil). Non-2xx responses become errors carrying GitHub's message.
+func (gc *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var rdr io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		rdr = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, apiBase+path, rdr)
+	if err != nil {
+		return err
+	}
+	if gc.token != "" {
+		req.Header.Set("Authorization", "Bearer "+gc.token)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := gc.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("GitHub request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		var errBody struct {
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(res.Body).Decode(&errBody)
+		return fmt.Errorf("GitHub %s %s: %s %s", method, path, res.Status, strings.TrimSpace(errBody.Message))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+// CommitStatus is the body of a commit status; State is one of
+// "pending", "success", "failure" or "error".
+type CommitStatus struct {
+	State       string `json:"state"`
+	TargetURL   string `json:"target_url,omitempty"`
+	Description string `json:"description,omitempty"`
+	Context     string `json:"context"`
+}
+
+// CreateStatus sets a commit status on sha. GitHub keeps the latest status per
+// context, so posting again with the same context replaces the previous one.
+func (gc *Client) CreateStatus(ctx context.Context, owner, repo, sha string, st CommitStatus) error {
+	if len(st.Description) > 140 {
+		st.Description = st.Description[:137] + "..."
+	}
+	path := fmt.Sprintf("/repos/%s/%s/statuses/%s", url.PathEscape(owner), url.PathEscape(repo), url.PathEscape(sha))
+	return gc.do(ctx, "POST", path, st, nil)
+}
+
+// GitHub lists at most this many files for a pull request.
+const maxPullFilePages = 30
+
+// ListPullRequestFiles returns the paths a pull request changes (renamed files
+// are listed under both names). complete is false when GitHub's 3000-file
+// limit was reached.
+func (gc *Client) ListPullRequestFiles(ctx context.Context, owner, repo string, number int) (files []string, complete bool, err error) {
+	for page := 1; page <= maxPullFilePages; page++ {
+		var batch []struct {
+			Filename         string `json:"filename"`
+			PreviousFilename string `json:"previous_filename"`
+		}
+		path := fmt.Sprintf("/repos/%s/%s/pulls/%d/files?per_page=100&page=%d", url.PathEscape(owner), url.PathEscape(repo), number, page)
+		if err := gc.do(ctx, "GET", path, nil, &batch); err != nil {
+			return nil, false, err
+		}
+		for _, f := range batch {
+			files = append(files, f.Filename)
+			if f.PreviousFilename != "" {
+				files = append(files, f.PreviousFilename)
+			}
+		}
+		if len(batch) < 100 {
+			return files, true, nil
+		}
+	}
+	return files, false, nil
+}
+
+// UpsertIssueComment keeps one comment per marker on an issue or pull
+// request: the first call creates it, later calls edit it in place. The
+// marker (an HTML comment) is prepended to body.
+func (gc *Client) UpsertIssueComment(ctx context.Context, owner, repo string, number int, marker, body string) error {
+	o, r := url.PathEscape(owner), url.PathEscape(repo)
+	payload := map[string]string{"body": marker + "\n" + body}
+
+	for page := 1; ; page++ {
+		var comments []struct {
+			ID   int64  `json:"id"`
+			Body string `json:"body"`
+		}
+		path := fmt.Sprintf("/repos/%s/%s/issues/%d/comments?per_page=100&page=%d", o, r, number, page)
+		if err := gc.do(ctx, "GET", path, nil, &comments); err != nil {
+			return err
+		}
+		for _, cm := range comments {
+			if strings.HasPrefix(cm.Body, marker) {
+				return gc.do(ctx, "PATCH", fmt.Sprintf("/repos/%s/%s/issues/comments/%d", o, r, cm.ID), payload, nil)
+			}
+		}
+		if len(comments) < 100 {
+			break
+		}
+	}
+	return gc.do(ctx, "POST", fmt.Sprintf("/repos/%s/%s/issues/%d/comments", o, r, number), payload, nil)
+}
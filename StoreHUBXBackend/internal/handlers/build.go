@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -10,11 +11,12 @@ import (
 	"github.com/rishyym0927/storehubx/internal/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // POST /api/components/:slug/versions/:version/build
 func EnqueueBuild(c *fiber.Ctx) error {
-	slug := c.Params("slug")
+	slug := slugParam(c)
 	versionStr := c.Params("version")
 	if slug == "" || versionStr == "" {
 		return utils.Error(c, 400, "missing slug or version")
@@ -55,7 +57,6 @@ func EnqueueBuild(c *fiber.Ctx) error {
 			Ref:    comp.RepoLink.Ref,
 			Commit: comp.RepoLink.Commit,
 		},
-		Logs:      []string{"enqueued"},
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -66,6 +67,7 @@ func EnqueueBuild(c *fiber.Ctx) error {
 		return utils.Error(c, 500, "failed to enqueue build")
 	}
 	oid, _ := res.InsertedID.(primitive.ObjectID)
+	logBuild(ctx, oid, "enqueued")
 
 	// 4) Optionally update version build state => queued
 	_, _ = verCol.UpdateOne(ctx,
@@ -91,8 +93,9 @@ func GetBuild(c *fiber.Ctx) error {
 	defer cancel()
 
 	jobCol := db.Client.Database("storehub").Collection("build_jobs")
+	// legacy jobs carry their whole log inline; it is served by /logs instead
 	var job models.BuildJob
-	if err := jobCol.FindOne(ctx, bson.M{"_id": oid}).Decode(&job); err != nil {
+	if err := jobCol.FindOne(ctx, bson.M{"_id": oid}, options.FindOne().SetProjection(bson.M{"logs": 0})).Decode(&job); err != nil {
 		return utils.Error(c, 404, "build not found")
 	}
 
@@ -100,8 +103,9 @@ func GetBuild(c *fiber.Ctx) error {
 }
 
 // (Optional) GET /api/components/:slug/versions/:version/builds  -> list history
+// Builds are returned oldest first; "chains" groups job IDs by retry lineage.
 func ListBuildsForVersion(c *fiber.Ctx) error {
-	slug := c.Params("slug")
+	slug := slugParam(c)
 	versionStr := c.Params("version")
 	if slug == "" || versionStr == "" {
 		return utils.Error(c, 400, "missing slug or version")
@@ -111,16 +115,214 @@ func ListBuildsForVersion(c *fiber.Ctx) error {
 	defer cancel()
 
 	jobCol := db.Client.Database("storehub").Collection("build_jobs")
-	cur, err := jobCol.Find(ctx, bson.M{"component": slug, "version": versionStr}, nil)
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}).SetProjection(bson.M{"logs": 0})
+	cur, err := jobCol.Find(ctx, bson.M{"component": slug, "version": versionStr}, opts)
 	if err != nil {
 		return utils.Error(c, 500, "db error")
 	}
 	defer cur.Close(ctx)
 
-	var jobs []models.BuildJob
+	jobs := make([]models.BuildJob, 0)
 	if err := cur.All(ctx, &jobs); err != nil {
 		return utils.Error(c, 500, "decode error")
 	}
 
-	return utils.Success(c, fiber.Map{"builds": jobs})
+	return utils.Success(c, fiber.Map{
+		"builds": jobs,
+		"chains": retryChains(jobs),
+	})
+}
+
+// retryChains follows parentJobId links and returns each lineage as a list of
+// job IDs, original build first. jobs must be sorted oldest first.
+func retryChains(jobs []models.BuildJob) [][]string {
+	chainOf := make(map[primitive.ObjectID]int, len(jobs))
+	chains := make([][]string, 0)
+	for _, j := range jobs {
+		if j.ParentJobID != nil {
+			if idx, ok := chainOf[*j.ParentJobID]; ok {
+				chains[idx] = append(chains[idx], j.ID.Hex())
+				chainOf[j.ID] = idx
+				continue
+			}
+		}
+		chainOf[j.ID] = len(chains)
+		chains = append(chains, []string{j.ID.Hex()})
+	}
+	return chains
+}
+
+type retryPayload struct {
+	ClearCache bool `json:"clearCache"`
+}
+
+// POST /api/builds/:id/retry  (protected, owner only)
+// Re-enqueues a failed or canceled build with the same repo spec, linked to it as a retry.
+func RetryBuild(c *fiber.Ctx) error {
+	oid, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return utils.Error(c, 400, "invalid id")
+	}
+
+	var body retryPayload
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&body); err != nil {
+			return utils.Error(c, 400, "invalid JSON body")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	jobCol := db.Client.Database("storehub").Collection("build_jobs")
+	var parent models.BuildJob
+	if err := jobCol.FindOne(ctx, bson.M{"_id": oid}).Decode(&parent); err != nil {
+		return utils.Error(c, 404, "build not found")
+	}
+
+	uid, _ := c.Locals("user_id").(string)
+	if !canManageBuild(ctx, &parent, uid) {
+		return utils.Error(c, 403, "only the component owner can retry this build")
+	}
+	if parent.Status != models.BuildError && parent.Status != models.BuildCanceled {
+		return utils.Error(c, 409, fmt.Sprintf("only failed or canceled builds can be retried (status: %s)", parent.Status))
+	}
+
+	// one retry per failed job keeps the chain linear (jobs retried before
+	// retriedAs existed are only found by their child)
+	if parent.RetriedAs != nil {
+		return utils.Error(c, 409, fmt.Sprintf("build was already retried as %s", parent.RetriedAs.Hex()))
+	}
+	var existing models.BuildJob
+	if err := jobCol.FindOne(ctx, bson.M{"parentJobId": parent.ID}).Decode(&existing); err == nil {
+		return utils.Error(c, 409, fmt.Sprintf("build was already retried as %s", existing.ID.Hex()))
+	}
+
+	// pull request previews have no version document to check or update
+	verCol := db.Client.Database("storehub").Collection("component_versions")
+	var ver models.ComponentVersion
+	if parent.PullRequest == nil {
+		if err := verCol.FindOne(ctx, bson.M{"componentId": parent.ComponentID, "version": parent.Version}).Decode(&ver); err != nil {
+			return utils.Error(c, 404, "version not found")
+		}
+	}
+
+	// take the parent's retry slot first, so concurrent retries cannot both pass
+	newID := primitive.NewObjectID()
+	claimed, err := jobCol.UpdateOne(ctx,
+		bson.M{"_id": parent.ID, "retriedAs": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"retriedAs": newID}},
+	)
+	if err != nil {
+		return utils.Error(c, 500, "failed to enqueue retry")
+	}
+	if claimed.MatchedCount == 0 {
+		return utils.Error(c, 409, "build was already retried")
+	}
+
+	job := models.BuildJob{
+		ID:          newID,
+		ComponentID: parent.ComponentID,
+		Component:   parent.Component,
+		Version:     parent.Version,
+		Status:      models.BuildQueued,
+		OwnerID:     uid,
+		Repo:        parent.Repo,
+		ParentJobID: &parent.ID,
+		RetryNumber: parent.RetryNumber + 1,
+		ClearCache:  body.ClearCache,
+		PullRequest: parent.PullRequest,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if _, err := jobCol.InsertOne(ctx, job); err != nil {
+		_, _ = jobCol.UpdateOne(ctx, bson.M{"_id": parent.ID, "retriedAs": newID}, bson.M{"$unset": bson.M{"retriedAs": ""}})
+		return utils.Error(c, 500, "failed to enqueue retry")
+	}
+	logBuild(ctx, newID, fmt.Sprintf("enqueued - retry #%d of %s", job.RetryNumber, parent.ID.Hex()))
+
+	if parent.PullRequest == nil {
+		_, _ = verCol.UpdateOne(ctx,
+			bson.M{"_id": ver.ID},
+			bson.M{"$set": bson.M{"buildState": models.VersionBuildQueued}},
+		)
+	}
+
+	return utils.Success(c, fiber.Map{
+		"jobId":       newID.Hex(),
+		"parentJobId": parent.ID.Hex(),
+		"retryNumber": job.RetryNumber,
+		"status":      "queued",
+	})
+}
+
+// POST /api/builds/:id/cancel  (protected, owner only)
+// Queued jobs are canceled immediately; running jobs are flagged and the worker
+// stops them (killing the build process) on its next heartbeat.
+func CancelBuild(c *fiber.Ctx) error {
+	oid, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return utils.Error(c, 400, "invalid id")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	jobCol := db.Client.Database("storehub").Collection("build_jobs")
+	var job models.BuildJob
+	if err := jobCol.FindOne(ctx, bson.M{"_id": oid}).Decode(&job); err != nil {
+		return utils.Error(c, 404, "build not found")
+	}
+
+	uid, _ := c.Locals("user_id").(string)
+	if !canManageBuild(ctx, &job, uid) {
+		return utils.Error(c, 403, "only the component owner can cancel this build")
+	}
+
+	now := time.Now()
+	res, err := jobCol.UpdateOne(ctx,
+		bson.M{"_id": oid, "status": models.BuildQueued},
+		bson.M{
+			"$set": bson.M{"status": models.BuildCanceled, "endedAt": now, "updatedAt": now},
+		},
+	)
+	if err != nil {
+		return utils.Error(c, 500, "failed to cancel build")
+	}
+	if res.ModifiedCount == 1 {
+		logBuild(ctx, oid, "canceled by user")
+		verCol := db.Client.Database("storehub").Collection("component_versions")
+		_, _ = verCol.UpdateOne(ctx,
+			bson.M{"componentId": job.ComponentID, "version": job.Version},
+			bson.M{"$set": bson.M{"buildState": models.VersionBuildCanceled}},
+		)
+		return utils.Success(c, fiber.Map{"jobId": oid.Hex(), "status": models.BuildCanceled})
+	}
+
+	res, err = jobCol.UpdateOne(ctx,
+		bson.M{"_id": oid, "status": models.BuildRunning},
+		bson.M{
+			"$set": bson.M{"cancelRequested": true, "updatedAt": now},
+		},
+	)
+	if err != nil {
+		return utils.Error(c, 500, "failed to cancel build")
+	}
+	if res.ModifiedCount == 1 {
+		logBuild(ctx, oid, "cancel requested by user")
+		return utils.Success(c, fiber.Map{"jobId": oid.Hex(), "status": "canceling"})
+	}
+
+	return utils.Error(c, 409, "build already finished")
+}
+
+// canManageBuild reports whether uid owns the job's component. Having
+// enqueued the build is not enough: anyone may enqueue one.
+func canManageBuild(ctx context.Context, job *models.BuildJob, uid string) bool {
+	if uid == "" {
+		return false
+	}
+	compCol := db.Client.Database("storehub").Collection("components")
+	n, err := compCol.CountDocuments(ctx, bson.M{"_id": job.ComponentID, "ownerId": uid})
+	return err == nil && n > 0
 }
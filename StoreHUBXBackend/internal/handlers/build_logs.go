@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rishyym0927/storehubx/internal/auth"
+	"github.com/rishyym0927/storehubx/internal/buildlog"
+	"github.com/rishyym0927/storehubx/internal/db"
+	"github.com/rishyym0927/storehubx/internal/models"
+	"github.com/rishyym0927/storehubx/internal/utils"
+	"github.com/rishyym0927/storehubx/internal/worker"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	defaultLogPageSize = 200
+	maxLogPageSize     = 1000
+	logPollInterval    = 500 * time.Millisecond
+	sseKeepAlive       = 15 * time.Second
+	maxStreamDuration  = 2 * time.Hour
+)
+
+type logPage struct {
+	Lines  []buildlog.Line    `json:"lines"`
+	Next   int                `json:"next"`  // pass as ?after= to get the following page
+	Total  int                `json:"total"` // lines stored so far
+	Status models.BuildStatus `json:"status"`
+	Done   bool               `json:"done"` // build finished and every line was returned
+}
+
+// buildLogs returns the API's view of the build_logs collection. It is cheap and
+// stateless between requests: sequence numbers are re-read from the collection.
+func buildLogs() *buildlog.Store {
+	return buildlog.NewStore(db.Client.Database("storehub").Collection("build_logs"))
+}
+
+// logBuild appends a system line to a job's log; failures are only printed.
+func logBuild(ctx context.Context, jobID primitive.ObjectID, msg string) {
+	if err := buildLogs().Append(ctx, jobID, buildlog.System, "", msg); err != nil {
+		fmt.Printf("WARNING: could not write build log for %s: %v\n", jobID.Hex(), err)
+	}
+}
+
+// fetchLogPage returns up to limit log lines after seq `after`. Jobs created
+// before the log store existed are served from their inline logs array.
+func fetchLogPage(ctx context.Context, jobID primitive.ObjectID, after, limit int) (*logPage, error) {
+	jobCol := db.Client.Database("storehub").Collection("build_jobs")
+	cur, err := jobCol.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"_id": jobID}}},
+		{{Key: "$project", Value: bson.M{
+			"status":      1,
+			"legacyTotal": bson.M{"$size": bson.M{"$ifNull": bson.A{"$logs", bson.A{}}}},
+			"legacy":      bson.M{"$slice": bson.A{bson.M{"$ifNull": bson.A{"$logs", bson.A{}}}, after, limit}},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	if !cur.Next(ctx) {
+		if err := cur.Err(); err != nil {
+			return nil, err
+		}
+		return nil, mongo.ErrNoDocuments
+	}
+	var row struct {
+		Status      models.BuildStatus `bson:"status"`
+		LegacyTotal int                `bson:"legacyTotal"`
+		Legacy      []string           `bson:"legacy"`
+	}
+	if err := cur.Decode(&row); err != nil {
+		return nil, err
+	}
+
+	page := &logPage{Status: row.Status}
+	store := buildLogs()
+	if page.Total, err = store.LastSeq(ctx, jobID); err != nil {
+		return nil, err
+	}
+	if page.Total == 0 && row.LegacyTotal > 0 {
+		page.Total = row.LegacyTotal
+		page.Lines = make([]buildlog.Line, 0, len(row.Legacy))
+		for i, text := range row.Legacy {
+			page.Lines = append(page.Lines, buildlog.Line{Seq: after + i + 1, Stream: buildlog.System, Text: text})
+		}
+	} else if page.Lines, err = store.Page(ctx, jobID, after, limit); err != nil {
+		return nil, err
+	}
+
+	page.Next = after
+	if n := len(page.Lines); n > 0 {
+		page.Next = page.Lines[n-1].Seq
+	}
+	page.Done = isTerminal(row.Status) && page.Next >= page.Total
+	return page, nil
+}
+
+func isTerminal(s models.BuildStatus) bool {
+	return s == models.BuildSuccess || s == models.BuildError || s == models.BuildCanceled
+}
+
+// GET /api/builds/:id/logs?after=N&limit=M
+func GetBuildLogs(c *fiber.Ctx) error {
+	oid, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return utils.Error(c, 400, "invalid id")
+	}
+	after, _ := strconv.Atoi(c.Query("after", "0"))
+	if after < 0 {
+		after = 0
+	}
+	limit, _ := strconv.Atoi(c.Query("limit", strconv.Itoa(defaultLogPageSize)))
+	if limit < 1 || limit > maxLogPageSize {
+		limit = defaultLogPageSize
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	page, err := fetchLogPage(ctx, oid, after, limit)
+	if err == mongo.ErrNoDocuments {
+		return utils.Error(c, 404, "build not found")
+	}
+	if err != nil {
+		return utils.Error(c, 500, "failed to read logs")
+	}
+	return utils.Success(c, page)
+}
+
+// POST /api/builds/:id/logs/stream-token
+// Issues a short-lived token that opens this build's log stream as
+// ?token=, for EventSource clients that cannot send an Authorization header.
+func IssueLogStreamToken(c *fiber.Ctx) error {
+	oid, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return utils.Error(c, 400, "invalid id")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	jobCol := db.Client.Database("storehub").Collection("build_jobs")
+	if err := jobCol.FindOne(ctx, bson.M{"_id": oid}).Err(); err == mongo.ErrNoDocuments {
+		return utils.Error(c, 404, "build not found")
+	} else if err != nil {
+		return utils.Error(c, 500, "database error")
+	}
+
+	uid, _ := c.Locals("user_id").(string)
+	email, _ := c.Locals("email").(string)
+	token, exp, err := auth.GenerateStreamToken(uid, email, oid.Hex())
+	if err != nil {
+		return utils.Error(c, 500, "failed to issue stream token")
+	}
+	return utils.Success(c, fiber.Map{
+		"token":     token,
+		"expiresAt": exp.UTC(),
+		"streamUrl": "/api/builds/" + oid.Hex() + "/logs/stream?token=" + token,
+	})
+}
+
+// GET /api/builds/:id/logs/stream  (Server-Sent Events)
+// Authenticated by the Authorization header or by ?token= from IssueLogStreamToken.
+// Emits one "log" event per line (data is the line text) with the line's seq as event id, so browsers
+// resume automatically via Last-Event-ID; ?after=N does the same explicitly.
+// An "end" event carrying the final status closes the stream.
+func StreamBuildLogs(c *fiber.Ctx) error {
+	oid, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return utils.Error(c, 400, "invalid id")
+	}
+	after, _ := strconv.Atoi(c.Query("after", "0"))
+	if lastID, err := strconv.Atoi(c.Get("Last-Event-ID")); err == nil && lastID > after {
+		after = lastID
+	}
+	if after < 0 {
+		after = 0
+	}
+
+	// fail fast with a normal JSON error if the build does not exist
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	_, err = fetchLogPage(ctx, oid, after, 1)
+	cancel()
+	if err == mongo.ErrNoDocuments {
+		return utils.Error(c, 404, "build not found")
+	}
+	if err != nil {
+		return utils.Error(c, 500, "failed to read logs")
+	}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no") // disable proxy buffering (nginx)
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx, cancel := context.WithTimeout(context.Background(), maxStreamDuration)
+		defer cancel()
+
+		fmt.Fprintf(w, "retry: %d\n\n", 2000)
+		if w.Flush() != nil {
+			return
+		}
+
+		next := after
+		lastWrite := time.Now()
+		ticker := time.NewTicker(logPollInterval)
+		defer ticker.Stop()
+		for {
+			page, err := fetchLogPage(ctx, oid, next, maxLogPageSize)
+			if err != nil {
+				writeSSE(w, "", "error", `{"error":"failed to read logs"}`)
+				_ = w.Flush()
+				return
+			}
+			for _, line := range page.Lines {
+				writeSSE(w, strconv.Itoa(line.Seq), "log", line.Text)
+			}
+			next = page.Next
+			if len(page.Lines) > 0 {
+				lastWrite = time.Now()
+				if w.Flush() != nil {
+					return // client went away
+				}
+			}
+			if page.Done {
+				end, _ := json.Marshal(fiber.Map{"status": page.Status, "next": page.Next})
+				writeSSE(w, "", "end", string(end))
+				_ = w.Flush()
+				return
+			}
+			if time.Since(lastWrite) >= sseKeepAlive {
+				fmt.Fprint(w, ": keep-alive\n\n")
+				if w.Flush() != nil {
+					return
+				}
+				lastWrite = time.Now()
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+	return nil
+}
+
+// GET /api/builds/:id/logs/download
+// Returns the full log as plain text. Once the lines have aged out of the log
+// store, finished builds serve the copy archived in storage.
+func DownloadBuildLogs(c *fiber.Ctx) error {
+	oid, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return utils.Error(c, 400, "invalid id")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	jobCol := db.Client.Database("storehub").Collection("build_jobs")
+	var job models.BuildJob
+	if err := jobCol.FindOne(ctx, bson.M{"_id": oid}).Decode(&job); err != nil {
+		return utils.Error(c, 404, "build not found")
+	}
+
+	var buf bytes.Buffer
+	n, err := buildLogs().WriteTo(ctx, oid, &buf)
+	if err != nil {
+		return utils.Error(c, 500, "failed to read logs")
+	}
+	if n == 0 {
+		if job.Artifacts != nil && job.Artifacts.LogURL != "" {
+			// read the archive through storage so private buckets work too
+			if uploader == nil {
+				return c.Redirect(job.Artifacts.LogURL, fiber.StatusFound)
+			}
+			archived, err := uploader.Get(ctx, worker.LogArchiveKey(oid))
+			if err != nil {
+				return c.Redirect(job.Artifacts.LogURL, fiber.StatusFound)
+			}
+			buf.Write(archived)
+		} else {
+			for _, line := range job.Logs {
+				buf.WriteString(line + "\n")
+			}
+		}
+	}
+
+	c.Set("Content-Type", "text/plain; charset=utf-8")
+	c.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="build-%s.log"`, oid.Hex()))
+	return c.Send(buf.Bytes())
+}
+
+// writeSSE writes one event; multi-line payloads become several data: lines.
+func writeSSE(w *bufio.Writer, id, event, data string) {
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	if event != "" {
+		fmt.Fprintf(w, "event: %s\n", event)
+	}
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(w, "data: %s\n", strings.TrimRight(line, "\r"))
+	}
+	w.WriteString("\n")
+}
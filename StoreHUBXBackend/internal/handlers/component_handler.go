@@ -2,6 +2,10 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -11,14 +15,23 @@ import (
 	"github.com/rishyym0927/storehubx/internal/models"
 	"github.com/rishyym0927/storehubx/internal/utils"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+type createComponentPayload struct {
+	models.Component
+	// Scoped publishes the component under the owner's namespace ("@username/slug")
+	// so it does not compete for the global name.
+	Scoped bool `json:"scoped"`
+}
+
 //
 // POST /api/components  (protected)
 //
 func CreateComponent(c *fiber.Ctx) error {
-	var body models.Component
+	var body createComponentPayload
 	if err := c.BodyParser(&body); err != nil {
 		return utils.Error(c, 400, "invalid JSON body")
 	}
@@ -26,27 +39,144 @@ func CreateComponent(c *fiber.Ctx) error {
 		return utils.Error(c, 400, "component name and frameworks are required")
 	}
 
-	uid, _ := c.Locals("user_id").(string)
-	body.OwnerID = uid
-	now := time.Now()
-	body.CreatedAt = now
-	body.UpdatedAt = now
-	body.Slug = strings.ToLower(strings.ReplaceAll(body.Name, " ", "-"))
+	base := utils.Slugify(body.Name)
+	if err := utils.ValidateSlug(base); err != nil {
+		// reserved words only clash with routes when used as a global slug
+		if !(body.Scoped && errors.Is(err, utils.ErrReservedSlug)) {
+			return utils.Error(c, 400, fmt.Sprintf("invalid component name %q: %v", body.Name, err))
+		}
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	uid, _ := c.Locals("user_id").(string)
+	username := lookupUsername(ctx, uid)
+
+	comp := body.Component
+	comp.ID = primitive.NilObjectID
+	comp.OwnerID = uid
+	now := time.Now()
+	comp.CreatedAt = now
+	comp.UpdatedAt = now
+	comp.RepoLink = models.RepoLink{}
+
+	makeSlug := func(name string) string { return name }
+	if body.Scoped {
+		scope := utils.Slugify(username)
+		if scope == "" {
+			return utils.Error(c, 400, "scoped components require a GitHub username on your profile")
+		}
+		comp.Scope = scope
+		makeSlug = func(name string) string { return utils.ScopedSlug(scope, name) }
+	}
+	comp.Slug = makeSlug(base)
+
 	col := db.Client.Database("storehub").Collection("components")
-	if _, err := col.InsertOne(ctx, body); err != nil {
+	if n, err := col.CountDocuments(ctx, bson.M{"slug": comp.Slug}); err != nil {
+		return utils.Error(c, 500, "database error")
+	} else if n > 0 {
+		return slugConflict(c, comp.Slug, suggestSlugs(ctx, col, base, makeSlug, username, body.Scoped))
+	}
+
+	res, err := col.InsertOne(ctx, comp)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return slugConflict(c, comp.Slug, suggestSlugs(ctx, col, base, makeSlug, username, body.Scoped))
+		}
 		return utils.Error(c, 500, "failed to insert component")
 	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		comp.ID = oid
+	}
 
 	return utils.Success(c, fiber.Map{
 		"status":    "created",
-		"component": body,
+		"component": comp,
+	})
+}
+
+// slugConflict answers 409 with a few free alternatives the client can offer the user.
+func slugConflict(c *fiber.Ctx, slug string, suggestions []string) error {
+	return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+		"success":     false,
+		"error":       fmt.Sprintf("a component with slug %q already exists", slug),
+		"suggestions": suggestions,
 	})
 }
 
+// suggestSlugs proposes up to three unused slugs derived from base.
+func suggestSlugs(ctx context.Context, col *mongo.Collection, base string, makeSlug func(string) string, username string, scoped bool) []string {
+	candidates := make([]string, 0, 8)
+	if scope := utils.Slugify(username); !scoped && scope != "" {
+		candidates = append(candidates, utils.ScopedSlug(scope, base))
+	}
+	if scope := utils.Slugify(username); scope != "" && !strings.HasSuffix(base, "-"+scope) {
+		candidates = append(candidates, makeSlug(trimSlug(base, len(scope)+1)+"-"+scope))
+	}
+	for n := 2; n <= 6; n++ {
+		suffix := "-" + strconv.Itoa(n)
+		candidates = append(candidates, makeSlug(trimSlug(base, len(suffix))+suffix))
+	}
+
+	taken := map[string]bool{}
+	cur, err := col.Find(ctx, bson.M{"slug": bson.M{"$in": candidates}},
+		options.Find().SetProjection(bson.M{"slug": 1}))
+	if err == nil {
+		var rows []struct {
+			Slug string `bson:"slug"`
+		}
+		if cur.All(ctx, &rows) == nil {
+			for _, r := range rows {
+				taken[r.Slug] = true
+			}
+		}
+	}
+
+	out := make([]string, 0, 3)
+	for _, cand := range candidates {
+		if !taken[cand] && len(out) < 3 {
+			out = append(out, cand)
+		}
+	}
+	return out
+}
+
+// trimSlug shortens base so that appending n more characters stays within MaxSlugLength.
+func trimSlug(base string, n int) string {
+	if max := utils.MaxSlugLength - n; len(base) > max && max > 0 {
+		return strings.TrimRight(base[:max], "-")
+	}
+	return base
+}
+
+// lookupUsername returns the GitHub login stored for a provider ID, or "".
+func lookupUsername(ctx context.Context, providerID string) string {
+	if providerID == "" {
+		return ""
+	}
+	var u struct {
+		Username string `bson:"username"`
+	}
+	err := db.Client.Database("storehub").Collection("users").
+		FindOne(ctx, bson.M{"providerId": providerID}, options.FindOne().SetProjection(bson.M{"username": 1})).
+		Decode(&u)
+	if err != nil {
+		return ""
+	}
+	return u.Username
+}
+
+// slugParam returns the :slug route parameter. Scoped slugs contain a slash,
+// so clients send them URL-encoded ("@alice%2Fbutton") and we decode here.
+func slugParam(c *fiber.Ctx) string {
+	raw := c.Params("slug")
+	if s, err := url.PathUnescape(raw); err == nil {
+		return s
+	}
+	return raw
+}
+
 //
 // GET /components  (public)  with q, framework, tags, page, limit
 //
@@ -130,7 +260,7 @@ func GetAllComponents(c *fiber.Ctx) error {
 // GET /components/:slug  (public)
 //
 func GetComponent(c *fiber.Ctx) error {
-	slug := c.Params("slug")
+	slug := slugParam(c)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -147,4 +277,172 @@ func GetComponent(c *fiber.Ctx) error {
 	})
 }
 
+type updateComponentPayload struct {
+	Name        *string   `json:"name"`
+	Description *string   `json:"description"`
+	Frameworks  *[]string `json:"frameworks"`
+	Tags        *[]string `json:"tags"`
+	License     *string   `json:"license"`
+}
+
+//
+// PATCH /api/components/:slug  (protected, owner only)
+//
+func UpdateComponent(c *fiber.Ctx) error {
+	slug := slugParam(c)
+
+	var body updateComponentPayload
+	if err := c.BodyParser(&body); err != nil {
+		return utils.Error(c, 400, "invalid JSON body")
+	}
+
+	set := bson.M{}
+	if body.Name != nil {
+		name := strings.TrimSpace(*body.Name)
+		if name == "" {
+			return utils.Error(c, 400, "component name cannot be empty")
+		}
+		set["name"] = name
+	}
+	if body.Description != nil {
+		set["description"] = strings.TrimSpace(*body.Description)
+	}
+	if body.Frameworks != nil {
+		frameworks := normalizeList(*body.Frameworks)
+		if len(frameworks) == 0 {
+			return utils.Error(c, 400, "at least one framework is required")
+		}
+		set["frameworks"] = frameworks
+	}
+	if body.Tags != nil {
+		set["tags"] = normalizeList(*body.Tags)
+	}
+	if body.License != nil {
+		set["license"] = strings.TrimSpace(*body.License)
+	}
+	if len(set) == 0 {
+		return utils.Error(c, 400, "nothing to update")
+	}
+	set["updatedAt"] = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	uid, _ := c.Locals("user_id").(string)
+	comp, ferr := findOwnedComponent(ctx, slug, uid)
+	if ferr != nil {
+		return utils.Error(c, ferr.Code, ferr.Message)
+	}
+
+	col := db.Client.Database("storehub").Collection("components")
+	var updated models.Component
+	err := col.FindOneAndUpdate(ctx,
+		bson.M{"_id": comp.ID},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err != nil {
+		return utils.Error(c, 500, "failed to update component")
+	}
+
+	return utils.Success(c, fiber.Map{
+		"status":    "updated",
+		"component": updated,
+	})
+}
+
+//
+// DELETE /api/components/:slug  (protected, owner only)
+// Removes the component together with its versions, build jobs and published files.
+//
+func DeleteComponent(c *fiber.Ctx) error {
+	slug := slugParam(c)
 
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	uid, _ := c.Locals("user_id").(string)
+	comp, ferr := findOwnedComponent(ctx, slug, uid)
+	if ferr != nil {
+		return utils.Error(c, ferr.Code, ferr.Message)
+	}
+
+	database := db.Client.Database("storehub")
+
+	verRes, err := database.Collection("component_versions").DeleteMany(ctx, bson.M{"componentId": comp.ID})
+	if err != nil {
+		return utils.Error(c, 500, "failed to delete component versions")
+	}
+	jobCol := database.Collection("build_jobs")
+	rawIDs, err := jobCol.Distinct(ctx, "_id", bson.M{"componentId": comp.ID})
+	if err != nil {
+		return utils.Error(c, 500, "failed to look up build jobs")
+	}
+	jobRes, err := jobCol.DeleteMany(ctx, bson.M{"componentId": comp.ID})
+	if err != nil {
+		return utils.Error(c, 500, "failed to delete build jobs")
+	}
+	jobIDs := make([]primitive.ObjectID, 0, len(rawIDs))
+	for _, id := range rawIDs {
+		if oid, ok := id.(primitive.ObjectID); ok {
+			jobIDs = append(jobIDs, oid)
+		}
+	}
+	if _, err := buildLogs().DeleteJobs(ctx, jobIDs); err != nil {
+		log.Printf("WARNING: failed to delete build logs for component %s: %v", comp.Slug, err)
+	}
+	if _, err := database.Collection("components").DeleteOne(ctx, bson.M{"_id": comp.ID}); err != nil {
+		return utils.Error(c, 500, "failed to delete component")
+	}
+
+	// Purging published files is best-effort: the database rows are already gone,
+	// so a storage hiccup must not turn the delete into a failure.
+	purged := 0
+	if uploader != nil {
+		n, err := uploader.DeletePrefix(ctx, "components/"+comp.Slug+"/")
+		if err != nil {
+			log.Printf("WARNING: failed to purge storage for component %s: %v", comp.Slug, err)
+		}
+		purged = n
+	}
+
+	return utils.Success(c, fiber.Map{
+		"status":          "deleted",
+		"slug":            comp.Slug,
+		"deletedVersions": verRes.DeletedCount,
+		"deletedBuilds":   jobRes.DeletedCount,
+		"purgedObjects":   purged,
+	})
+}
+
+// findOwnedComponent loads a component by slug and checks that uid owns it.
+func findOwnedComponent(ctx context.Context, slug, uid string) (*models.Component, *fiber.Error) {
+	col := db.Client.Database("storehub").Collection("components")
+
+	var comp models.Component
+	if err := col.FindOne(ctx, bson.M{"slug": slug}).Decode(&comp); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fiber.NewError(404, "component not found")
+		}
+		return nil, fiber.NewError(500, "database error")
+	}
+	if uid == "" || comp.OwnerID != uid {
+		return nil, fiber.NewError(403, "only the component owner can do this")
+	}
+	return &comp, nil
+}
+
+// normalizeList trims, lowercases and de-duplicates a list of tags/frameworks.
+func normalizeList(in []string) []string {
+	out := make([]string, 0, len(in))
+	seen := make(map[string]bool, len(in))
+	for _, v := range in {
+		v = strings.TrimSpace(strings.ToLower(v))
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		out = append(out, v)
+	}
+	return out
+}
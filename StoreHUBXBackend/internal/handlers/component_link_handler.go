@@ -20,10 +20,13 @@ type linkPayload struct {
 	Path   string `json:"path"`
 	Ref    string `json:"ref"`
 	Commit string `json:"commit"`
+
+	TagReleases bool   `json:"tagReleases"`
+	TagPrefix   string `json:"tagPrefix"`
 }
 
 func LinkComponentRepo(c *fiber.Ctx) error {
-	slug := c.Params("slug")
+	slug := slugParam(c)
 	if slug == "" {
 		return utils.Error(c, 400, "missing slug")
 	}
@@ -52,6 +55,9 @@ func LinkComponentRepo(c *fiber.Ctx) error {
 				"path":   body.Path,
 				"ref":    body.Ref,
 				"commit": body.Commit,
+
+				"tagReleases": body.TagReleases,
+				"tagPrefix":   body.TagPrefix,
 			},
 			"updatedAt": time.Now(),
 		},
@@ -83,7 +89,8 @@ func LinkComponentRepo(c *fiber.Ctx) error {
 
 	var firstVersion *models.ComponentVersion
 
-	if count == 0 && body.Commit != "" {
+	// In release mode versions come from tags, so none is made up here
+	if count == 0 && body.Commit != "" && !body.TagReleases {
 		// Create initial version (1.0.0)
 		firstVersion = &models.ComponentVersion{
 			ComponentID: updated.ID,
@@ -115,7 +122,6 @@ func LinkComponentRepo(c *fiber.Ctx) error {
 					Ref:    body.Ref,
 					Commit: body.Commit,
 				},
-				Logs:      []string{"enqueued - initial version"},
 				CreatedAt: time.Now(),
 				UpdatedAt: time.Now(),
 			}
@@ -124,6 +130,7 @@ func LinkComponentRepo(c *fiber.Ctx) error {
 			jobRes, err := jobCol.InsertOne(ctx, job)
 			if err == nil {
 				jobID, _ := jobRes.InsertedID.(primitive.ObjectID)
+				logBuild(ctx, jobID, "enqueued - initial version")
 				fmt.Printf("Created initial build job: %s\n", jobID.Hex())
 			}
 		}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rishyym0927/storehubx/internal/db"
+	"github.com/rishyym0927/storehubx/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// deployRequest describes a commit to publish as a new version of a linked component.
+type deployRequest struct {
+	CommitSHA string
+	Version   string // optional; the next version is generated when empty
+	Changelog string // optional
+	UserID    string // recorded as the version's creator; the build uses this user's GitHub token
+	Source    string // shows up in the first build log line, e.g. "auto-deploy"
+}
+
+// deployCommit creates a version for req.CommitSHA and enqueues its build. It is
+// shared by AutoDeploy and the GitHub webhook.
+func deployCommit(ctx context.Context, comp *models.Component, req deployRequest) (*models.ComponentVersion, primitive.ObjectID, *fiber.Error) {
+	// Verify component is linked
+	if comp.RepoLink.Owner == "" || comp.RepoLink.Repo == "" {
+		return nil, primitive.NilObjectID, fiber.NewError(400, "component is not linked to a repository")
+	}
+
+	verCol := db.Client.Database("storehub").Collection("component_versions")
+
+	// Check if version exists for this commit
+	var existingVersion models.ComponentVersion
+	err := verCol.FindOne(ctx, bson.M{
+		"componentId": comp.ID,
+		"commitSha":   req.CommitSHA,
+	}).Decode(&existingVersion)
+
+	if err == nil {
+		return nil, primitive.NilObjectID, fiber.NewError(409, fmt.Sprintf("version already exists for this commit: %s", existingVersion.Version))
+	}
+
+	// Auto-generate version number if not provided
+	versionNumber := req.Version
+	if versionNumber == "" {
+		versionNumber = generateNextVersion(ctx, verCol, comp.ID)
+	} else if ferr := checkNewVersion(ctx, verCol, comp.ID, versionNumber); ferr != nil {
+		return nil, primitive.NilObjectID, ferr
+	}
+
+	// Create new version
+	newVersion := models.ComponentVersion{
+		ComponentID: comp.ID,
+		Version:     versionNumber,
+		Changelog:   req.Changelog,
+		CommitSHA:   req.CommitSHA,
+		BuildState:  models.VersionBuildQueued,
+		CreatedBy:   req.UserID,
+		CreatedAt:   time.Now(),
+	}
+
+	if newVersion.Changelog == "" {
+		newVersion.Changelog = fmt.Sprintf("Auto-deployed from commit %s", shortSHA(req.CommitSHA))
+	}
+
+	insertResult, err := verCol.InsertOne(ctx, newVersion)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, primitive.NilObjectID, fiber.NewError(409, fmt.Sprintf("version %s already exists", versionNumber))
+		}
+		return nil, primitive.NilObjectID, fiber.NewError(500, "failed to create version")
+	}
+
+	newVersion.ID = insertResult.InsertedID.(primitive.ObjectID)
+
+	// Create build job
+	job := models.BuildJob{
+		ComponentID: comp.ID,
+		Component:   comp.Slug,
+		Version:     versionNumber,
+		Status:      models.BuildQueued,
+		OwnerID:     req.UserID,
+		Repo: models.BuildRepo{
+			Owner:  comp.RepoLink.Owner,
+			Repo:   comp.RepoLink.Repo,
+			Path:   comp.RepoLink.Path,
+			Ref:    comp.RepoLink.Ref,
+			Commit: req.CommitSHA,
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	jobCol := db.Client.Database("storehub").Collection("build_jobs")
+	jobRes, err := jobCol.InsertOne(ctx, job)
+	if err != nil {
+		return nil, primitive.NilObjectID, fiber.NewError(500, "failed to create build job")
+	}
+
+	jobID, _ := jobRes.InsertedID.(primitive.ObjectID)
+	logBuild(ctx, jobID, "enqueued - "+req.Source)
+
+	return &newVersion, jobID, nil
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
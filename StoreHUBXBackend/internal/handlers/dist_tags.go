@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rishyym0927/storehubx/internal/db"
+	"github.com/rishyym0927/storehubx/internal/models"
+	"github.com/rishyym0927/storehubx/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Tags start with a letter so they can never be mistaken for a version.
+var distTagPattern = regexp.MustCompile(`^[a-z][a-z0-9._-]{0,63}$`)
+
+type distTagPayload struct {
+	Version string `json:"version"`
+}
+
+// PUT /api/components/:slug/tags/:tag  (protected, owner only)
+// Body: { "version": "1.2.0" } — creates or moves the tag.
+func SetDistTag(c *fiber.Ctx) error {
+	slug := slugParam(c)
+	tag := strings.ToLower(c.Params("tag"))
+	if !distTagPattern.MatchString(tag) {
+		return utils.Error(c, 400, "invalid tag: use lowercase letters, digits, '.', '-' or '_', starting with a letter")
+	}
+
+	var body distTagPayload
+	if err := c.BodyParser(&body); err != nil {
+		return utils.Error(c, 400, "invalid JSON body")
+	}
+	body.Version = strings.TrimSpace(body.Version)
+	if body.Version == "" {
+		return utils.Error(c, 400, "version is required")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	uid, _ := c.Locals("user_id").(string)
+	comp, ferr := findOwnedComponent(ctx, slug, uid)
+	if ferr != nil {
+		return utils.Error(c, ferr.Code, ferr.Message)
+	}
+
+	verCol := db.Client.Database("storehub").Collection("component_versions")
+	var ver models.ComponentVersion
+	if err := verCol.FindOne(ctx, bson.M{"componentId": comp.ID, "version": body.Version}).Decode(&ver); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return utils.Error(c, 404, "version not found")
+		}
+		return utils.Error(c, 500, "database error")
+	}
+	if ver.Yanked {
+		return utils.Error(c, 409, "cannot tag a yanked version")
+	}
+
+	col := db.Client.Database("storehub").Collection("components")
+	if _, err := col.UpdateByID(ctx, comp.ID, bson.M{"$set": bson.M{
+		"distTags." + tag: body.Version,
+		"updatedAt":       time.Now(),
+	}}); err != nil {
+		return utils.Error(c, 500, "failed to set tag")
+	}
+
+	if comp.DistTags == nil {
+		comp.DistTags = map[string]string{}
+	}
+	comp.DistTags[tag] = body.Version
+	return utils.Success(c, fiber.Map{
+		"distTags": comp.DistTags,
+	})
+}
+
+// DELETE /api/components/:slug/tags/:tag  (protected, owner only)
+func DeleteDistTag(c *fiber.Ctx) error {
+	slug := slugParam(c)
+	tag := strings.ToLower(c.Params("tag"))
+	if tag == models.DistTagLatest {
+		return utils.Error(c, 400, "the latest tag cannot be removed; point it at another version instead")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	uid, _ := c.Locals("user_id").(string)
+	comp, ferr := findOwnedComponent(ctx, slug, uid)
+	if ferr != nil {
+		return utils.Error(c, ferr.Code, ferr.Message)
+	}
+	if _, ok := comp.DistTags[tag]; !ok {
+		return utils.Error(c, 404, "tag not found")
+	}
+
+	col := db.Client.Database("storehub").Collection("components")
+	if _, err := col.UpdateByID(ctx, comp.ID, bson.M{
+		"$unset": bson.M{"distTags." + tag: ""},
+		"$set":   bson.M{"updatedAt": time.Now()},
+	}); err != nil {
+		return utils.Error(c, 500, "failed to remove tag")
+	}
+
+	delete(comp.DistTags, tag)
+	return utils.Success(c, fiber.Map{
+		"distTags": comp.DistTags,
+	})
+}
+
+// resolveVersionRef finds the version a ref names: a dist-tag, an exact
+// version, or "latest" when the tag has never been set (the highest stable
+// installable version then). Yanked versions are only returned for an exact
+// match, and versions whose build has not finished never are.
+func resolveVersionRef(ctx context.Context, comp *models.Component, ref string) (*models.ComponentVersion, *fiber.Error) {
+	verCol := db.Client.Database("storehub").Collection("component_versions")
+
+	tagged, isTag := comp.DistTags[ref]
+	if isTag {
+		ref = tagged
+	} else if ref == models.DistTagLatest {
+		versions, err := loadVersions(ctx, verCol, comp.ID)
+		if err != nil {
+			return nil, fiber.NewError(500, "database error")
+		}
+		markLatest(versions)
+		for i := range versions {
+			if versions[i].Latest {
+				return &versions[i], nil
+			}
+		}
+		return nil, fiber.NewError(404, "component has no installable versions")
+	}
+
+	var v models.ComponentVersion
+	if err := verCol.FindOne(ctx, bson.M{"componentId": comp.ID, "version": ref}).Decode(&v); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, fiber.NewError(404, fmt.Sprintf("version %s not found", ref))
+		}
+		return nil, fiber.NewError(500, "database error")
+	}
+	if isTag && v.Yanked {
+		return nil, fiber.NewError(404, fmt.Sprintf("version %s was yanked", ref))
+	}
+	if !installable(v.BuildState) {
+		return nil, fiber.NewError(404, fmt.Sprintf("version %s has not been built (build state: %s)", ref, v.BuildState))
+	}
+	return &v, nil
+}
+
+// applyDistTags fills the computed Tags and Latest fields of a sorted version
+// list. Latest follows the "latest" tag when it points at a listed version.
+func applyDistTags(versions []models.ComponentVersion, distTags map[string]string) {
+	byVersion := make(map[string][]string, len(distTags))
+	for tag, v := range distTags {
+		byVersion[v] = append(byVersion[v], tag)
+	}
+
+	tagged := false
+	for i := range versions {
+		tags := byVersion[versions[i].Version]
+		if len(tags) == 0 {
+			continue
+		}
+		sort.Strings(tags)
+		versions[i].Tags = tags
+		if distTags[models.DistTagLatest] == versions[i].Version {
+			versions[i].Latest = true
+			tagged = true
+		}
+	}
+	if !tagged {
+		markLatest(versions)
+	}
+}
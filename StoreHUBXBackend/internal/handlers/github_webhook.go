@@ -0,0 +1,383 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rishyym0927/storehubx/internal/db"
+	githubapi "github.com/rishyym0927/storehubx/internal/github"
+	"github.com/rishyym0927/storehubx/internal/models"
+	"github.com/rishyym0927/storehubx/internal/semver"
+	"github.com/rishyym0927/storehubx/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GitHub sends at most this many commits in a push payload; longer pushes are
+// truncated, so their changed-file list is incomplete.
+const maxPushCommits = 20
+
+type ghRepository struct {
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	DefaultBranch string `json:"default_branch"`
+	Owner         struct {
+		Login string `json:"login"`
+		Name  string `json:"name"` // push events use name instead of login
+	} `json:"owner"`
+}
+
+func (r ghRepository) owner() string {
+	return firstNonEmptyStr(r.Owner.Login, r.Owner.Name)
+}
+
+type ghCommit struct {
+	ID       string   `json:"id"`
+	Message  string   `json:"message"`
+	Added    []string `json:"added"`
+	Removed  []string `json:"removed"`
+	Modified []string `json:"modified"`
+}
+
+type ghPushEvent struct {
+	Ref        string       `json:"ref"`
+	After      string       `json:"after"`
+	Deleted    bool         `json:"deleted"`
+	Repository ghRepository `json:"repository"`
+	Commits    []ghCommit   `json:"commits"`
+	HeadCommit *ghCommit    `json:"head_commit"`
+}
+
+type ghReleaseEvent struct {
+	Action  string `json:"action"`
+	Release struct {
+		TagName    string `json:"tag_name"`
+		Name       string `json:"name"`
+		Body       string `json:"body"`
+		Draft      bool   `json:"draft"`
+		Prerelease bool   `json:"prerelease"`
+	} `json:"release"`
+	Repository ghRepository `json:"repository"`
+}
+
+// webhookResult reports what happened to one linked component.
+type webhookResult struct {
+	Component string `json:"component"`
+	Version   string `json:"version,omitempty"`
+	JobID     string `json:"jobId,omitempty"`
+	Skipped   string `json:"skipped,omitempty"` // reason, when nothing was enqueued
+}
+
+// POST /webhooks/github  (public, authenticated by X-Hub-Signature-256)
+// Push events to a linked component's branch create a version and enqueue a
+// build when the push touches the component's folder. Components in release
+// mode (repoLink.tagReleases) are versioned from semver tags and published
+// releases instead. Pull requests get preview builds that are not versions.
+func GitHubWebhook(c *fiber.Ctx) error {
+	secret := os.Getenv("GITHUB_WEBHOOK_SECRET")
+	if secret == "" {
+		return utils.Error(c, 503, "webhooks are not configured")
+	}
+	if !validWebhookSignature(secret, c.Body(), c.Get("X-Hub-Signature-256")) {
+		return utils.Error(c, 401, "invalid signature")
+	}
+
+	// GitHub can be set up to send form-encoded payloads as well
+	payload := c.Body()
+	if strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEApplicationForm) {
+		payload = []byte(c.FormValue("payload"))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	event := c.Get("X-GitHub-Event")
+	switch event {
+	case "ping":
+		return utils.Success(c, fiber.Map{"event": event, "message": "pong"})
+	case "push":
+		var push ghPushEvent
+		if err := json.Unmarshal(payload, &push); err != nil {
+			return utils.Error(c, 400, "invalid push payload")
+		}
+		if tag, ok := strings.CutPrefix(push.Ref, "refs/tags/"); ok {
+			return utils.Success(c, fiber.Map{"event": event, "results": handleTagPush(ctx, &push, tag)})
+		}
+		return utils.Success(c, fiber.Map{"event": event, "results": handlePush(ctx, &push)})
+	case "release":
+		var rel ghReleaseEvent
+		if err := json.Unmarshal(payload, &rel); err != nil {
+			return utils.Error(c, 400, "invalid release payload")
+		}
+		if rel.Action != "published" || rel.Release.Draft {
+			return utils.Success(c, fiber.Map{"event": event, "message": "release action ignored"})
+		}
+		return utils.Success(c, fiber.Map{"event": event, "results": handleRelease(ctx, &rel)})
+	case "pull_request":
+		var pr ghPullRequestEvent
+		if err := json.Unmarshal(payload, &pr); err != nil {
+			return utils.Error(c, 400, "invalid pull_request payload")
+		}
+		return utils.Success(c, fiber.Map{"event": event, "results": handlePullRequest(ctx, &pr)})
+	default:
+		return utils.Success(c, fiber.Map{"event": event, "message": "event ignored"})
+	}
+}
+
+// validWebhookSignature checks GitHub's "sha256=<hex hmac of body>" header.
+func validWebhookSignature(secret string, body []byte, header string) bool {
+	sig, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+func handlePush(ctx context.Context, push *ghPushEvent) []webhookResult {
+	results := make([]webhookResult, 0)
+	branch, isBranch := strings.CutPrefix(push.Ref, "refs/heads/")
+	if !isBranch || push.Deleted || push.HeadCommit == nil {
+		return results
+	}
+
+	comps, err := linkedComponents(ctx, push.Repository)
+	if err != nil {
+		return results
+	}
+	changed, complete := changedFiles(push.Commits)
+
+	for i := range comps {
+		comp := &comps[i]
+		res := webhookResult{Component: comp.Slug}
+		switch {
+		case comp.RepoLink.TagReleases:
+			res.Skipped = "release mode: versions come from tags"
+		case firstNonEmptyStr(comp.RepoLink.Ref, push.Repository.DefaultBranch) != branch:
+			res.Skipped = "linked to a different branch"
+		case complete && !touchesPath(changed, comp.RepoLink.Path):
+			res.Skipped = "no changes under " + comp.RepoLink.Path
+		default:
+			ver, jobID, ferr := deployCommit(ctx, comp, deployRequest{
+				CommitSHA: push.After,
+				Changelog: firstLine(push.HeadCommit.Message),
+				UserID:    comp.OwnerID,
+				Source:    "push to " + branch,
+			})
+			if ferr != nil {
+				res.Skipped = ferr.Message
+			} else {
+				res.Version, res.JobID = ver.Version, jobID.Hex()
+			}
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+// handleTagPush creates a version for every release-mode component whose tag
+// pattern matches. Annotated and lightweight tags both carry the tagged commit
+// as head_commit; it is resolved through the API if missing.
+func handleTagPush(ctx context.Context, push *ghPushEvent, tag string) []webhookResult {
+	results := make([]webhookResult, 0)
+	if push.Deleted {
+		return results
+	}
+	comps, err := linkedComponents(ctx, push.Repository)
+	if err != nil {
+		return results
+	}
+
+	for i := range comps {
+		comp := &comps[i]
+		res := webhookResult{Component: comp.Slug}
+		version, ok := tagVersion(comp, tag)
+		if !ok {
+			res.Skipped = "not a release tag for this component"
+			results = append(results, res)
+			continue
+		}
+		commit := ""
+		if push.HeadCommit != nil {
+			commit = push.HeadCommit.ID
+		}
+		if commit == "" {
+			if commit, err = resolveTagCommit(ctx, comp, tag); err != nil {
+				res.Skipped = err.Error()
+				results = append(results, res)
+				continue
+			}
+		}
+		ver, jobID, ferr := deployCommit(ctx, comp, deployRequest{
+			CommitSHA: commit,
+			Version:   version,
+			Changelog: "Release " + tag,
+			UserID:    comp.OwnerID,
+			Source:    "tag " + tag,
+		})
+		if ferr != nil {
+			res.Skipped = ferr.Message
+		} else {
+			res.Version, res.JobID = ver.Version, jobID.Hex()
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+// handleRelease creates the release's version with its notes as changelog. If
+// the tag push got there first, only the changelog is filled in.
+func handleRelease(ctx context.Context, rel *ghReleaseEvent) []webhookResult {
+	results := make([]webhookResult, 0)
+	comps, err := linkedComponents(ctx, rel.Repository)
+	if err != nil {
+		return results
+	}
+	tag := rel.Release.TagName
+	changelog := strings.TrimSpace(rel.Release.Body)
+	if changelog == "" {
+		changelog = firstNonEmptyStr(strings.TrimSpace(rel.Release.Name), "Release "+tag)
+	}
+
+	verCol := db.Client.Database("storehub").Collection("component_versions")
+	for i := range comps {
+		comp := &comps[i]
+		res := webhookResult{Component: comp.Slug}
+		version, ok := tagVersion(comp, tag)
+		if !ok {
+			res.Skipped = "not a release tag for this component"
+			results = append(results, res)
+			continue
+		}
+		res.Version = version
+
+		upd, err := verCol.UpdateOne(ctx,
+			bson.M{"componentId": comp.ID, "version": version},
+			bson.M{"$set": bson.M{"changelog": changelog}},
+		)
+		if err == nil && upd.MatchedCount > 0 {
+			res.Skipped = "version exists; changelog updated from release notes"
+			results = append(results, res)
+			continue
+		}
+
+		commit, err := resolveTagCommit(ctx, comp, tag)
+		if err != nil {
+			res.Skipped = err.Error()
+			results = append(results, res)
+			continue
+		}
+		ver, jobID, ferr := deployCommit(ctx, comp, deployRequest{
+			CommitSHA: commit,
+			Version:   version,
+			Changelog: changelog,
+			UserID:    comp.OwnerID,
+			Source:    "release " + tag,
+		})
+		if ferr != nil {
+			res.Skipped = ferr.Message
+		} else {
+			res.Version, res.JobID = ver.Version, jobID.Hex()
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+// tagVersion extracts the version from a release tag if the component is in
+// release mode and the tag carries its prefix.
+func tagVersion(comp *models.Component, tag string) (string, bool) {
+	if !comp.RepoLink.TagReleases {
+		return "", false
+	}
+	rest, ok := strings.CutPrefix(tag, comp.RepoLink.TagPrefix)
+	if !ok {
+		return "", false
+	}
+	// release tags are usually written "v1.4.0"
+	v, err := semver.Parse(strings.TrimPrefix(rest, "v"))
+	if err != nil {
+		return "", false
+	}
+	return v.String(), true
+}
+
+// resolveTagCommit asks GitHub, as the component owner, which commit a tag points to.
+func resolveTagCommit(ctx context.Context, comp *models.Component, tag string) (string, error) {
+	token, err := githubapi.TokenForUser(ctx, comp.OwnerID)
+	if err != nil {
+		return "", err
+	}
+	return githubapi.NewClient(token).ResolveCommit(ctx, comp.RepoLink.Owner, comp.RepoLink.Repo, tag)
+}
+
+// linkedComponents finds the components linked to repo (owner and name are
+// compared case-insensitively, as on GitHub).
+func linkedComponents(ctx context.Context, repo ghRepository) ([]models.Component, error) {
+	compCol := db.Client.Database("storehub").Collection("components")
+	cur, err := compCol.Find(ctx, bson.M{
+		"repoLink.owner": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(repo.owner()) + "$", Options: "i"},
+		"repoLink.repo":  primitive.Regex{Pattern: "^" + regexp.QuoteMeta(repo.Name) + "$", Options: "i"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	comps := make([]models.Component, 0)
+	if err := cur.All(ctx, &comps); err != nil {
+		return nil, err
+	}
+	return comps, nil
+}
+
+// changedFiles collects every path added, removed or modified by the pushed
+// commits. complete is false when GitHub truncated the commit list.
+func changedFiles(commits []ghCommit) (files []string, complete bool) {
+	for _, cm := range commits {
+		files = append(files, cm.Added...)
+		files = append(files, cm.Removed...)
+		files = append(files, cm.Modified...)
+	}
+	return files, len(commits) < maxPushCommits
+}
+
+// touchesPath reports whether any file lies inside dir ("" or "." is the repo root).
+func touchesPath(files []string, dir string) bool {
+	dir = strings.Trim(path.Clean("/"+dir), "/")
+	if dir == "" {
+		return true
+	}
+	for _, f := range files {
+		if f == dir || strings.HasPrefix(f, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(line)
+}
+
+func firstNonEmptyStr(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
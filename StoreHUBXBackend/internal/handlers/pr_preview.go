@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rishyym0927/storehubx/internal/db"
+	githubapi "github.com/rishyym0927/storehubx/internal/github"
+	"github.com/rishyym0927/storehubx/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ghPullRequestEvent struct {
+	Action      string `json:"action"`
+	Number      int    `json:"number"`
+	PullRequest struct {
+		Title   string `json:"title"`
+		HTMLURL string `json:"html_url"`
+		Head    struct {
+			Ref  string        `json:"ref"`
+			SHA  string        `json:"sha"`
+			Repo *ghRepository `json:"repo"` // nil when the fork was deleted
+		} `json:"head"`
+		Base struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+	} `json:"pull_request"`
+	Repository ghRepository `json:"repository"`
+}
+
+// handlePullRequest builds previews for opened/updated pull requests and
+// removes them when the pull request is closed.
+func handlePullRequest(ctx context.Context, ev *ghPullRequestEvent) []webhookResult {
+	results := make([]webhookResult, 0)
+	comps, err := linkedComponents(ctx, ev.Repository)
+	if err != nil {
+		return results
+	}
+	for i := range comps {
+		comp := &comps[i]
+		var res webhookResult
+		switch ev.Action {
+		case "opened", "reopened", "synchronize":
+			res = enqueuePreview(ctx, comp, ev)
+		case "closed":
+			res = removePreview(ctx, comp, ev)
+		default:
+			continue
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+// enqueuePreview queues a preview build of the pull request head for comp,
+// superseding earlier preview builds of the same pull request.
+func enqueuePreview(ctx context.Context, comp *models.Component, ev *ghPullRequestEvent) webhookResult {
+	pr := &ev.PullRequest
+	res := webhookResult{Component: comp.Slug}
+
+	// Builds run as the component owner; code from forks is not trusted with that.
+	if pr.Head.Repo == nil || !strings.EqualFold(pr.Head.Repo.FullName, ev.Repository.FullName) {
+		res.Skipped = "pull requests from forks are not previewed"
+		return res
+	}
+	if firstNonEmptyStr(comp.RepoLink.Ref, ev.Repository.DefaultBranch) != pr.Base.Ref {
+		res.Skipped = "pull request targets a different branch"
+		return res
+	}
+	if token, err := githubapi.TokenForUser(ctx, comp.OwnerID); err == nil {
+		files, complete, err := githubapi.NewClient(token).ListPullRequestFiles(ctx, comp.RepoLink.Owner, comp.RepoLink.Repo, ev.Number)
+		if err == nil && complete && !touchesPath(files, comp.RepoLink.Path) {
+			res.Skipped = "no changes under " + comp.RepoLink.Path
+			return res
+		}
+	}
+
+	version := models.PreviewVersion(ev.Number)
+	jobCol := db.Client.Database("storehub").Collection("build_jobs")
+	supersedePreviews(ctx, comp, ev.Number)
+
+	job := models.BuildJob{
+		ComponentID: comp.ID,
+		Component:   comp.Slug,
+		Version:     version,
+		Status:      models.BuildQueued,
+		OwnerID:     comp.OwnerID,
+		Repo: models.BuildRepo{
+			Owner:  comp.RepoLink.Owner,
+			Repo:   comp.RepoLink.Repo,
+			Path:   comp.RepoLink.Path,
+			Ref:    pr.Head.Ref,
+			Commit: pr.Head.SHA,
+		},
+		PullRequest: &models.PullRequestRef{
+			Number:  ev.Number,
+			Title:   pr.Title,
+			URL:     pr.HTMLURL,
+			HeadRef: pr.Head.Ref,
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	jobRes, err := jobCol.InsertOne(ctx, job)
+	if err != nil {
+		res.Skipped = "failed to create build job"
+		return res
+	}
+	jobID, _ := jobRes.InsertedID.(primitive.ObjectID)
+	logBuild(ctx, jobID, fmt.Sprintf("enqueued - preview of pull request #%d at %s", ev.Number, shortSHA(pr.Head.SHA)))
+
+	res.Version, res.JobID = version, jobID.Hex()
+	return res
+}
+
+// supersedePreviews cancels the pull request's older preview builds: queued
+// ones immediately, running ones through the worker's cancel flag.
+func supersedePreviews(ctx context.Context, comp *models.Component, number int) {
+	jobCol := db.Client.Database("storehub").Collection("build_jobs")
+	filter := bson.M{"componentId": comp.ID, "pullRequest.number": number}
+	now := time.Now()
+
+	_, _ = jobCol.UpdateMany(ctx,
+		withStatus(filter, models.BuildQueued),
+		bson.M{"$set": bson.M{"status": models.BuildCanceled, "endedAt": now, "updatedAt": now}},
+	)
+	_, _ = jobCol.UpdateMany(ctx,
+		withStatus(filter, models.BuildRunning),
+		bson.M{"$set": bson.M{"cancelRequested": true, "updatedAt": now}},
+	)
+}
+
+// removePreview deletes the pull request's preview files, build jobs and their
+// logs, and notes the removal in the preview comment.
+func removePreview(ctx context.Context, comp *models.Component, ev *ghPullRequestEvent) webhookResult {
+	res := webhookResult{Component: comp.Slug, Version: models.PreviewVersion(ev.Number)}
+	jobCol := db.Client.Database("storehub").Collection("build_jobs")
+	filter := bson.M{"componentId": comp.ID, "pullRequest.number": ev.Number}
+
+	// a running build is stopped first; whatever it still uploads is left for storage GC
+	supersedePreviews(ctx, comp, ev.Number)
+
+	cur, err := jobCol.Find(ctx, filter)
+	if err != nil {
+		res.Skipped = "failed to look up preview builds"
+		return res
+	}
+	var jobs []models.BuildJob
+	if err := cur.All(ctx, &jobs); err != nil {
+		res.Skipped = "failed to look up preview builds"
+		return res
+	}
+	if len(jobs) == 0 {
+		res.Skipped = "no preview was built"
+		return res
+	}
+	ids := make([]primitive.ObjectID, 0, len(jobs))
+	for _, j := range jobs {
+		ids = append(ids, j.ID)
+	}
+	_, _ = buildLogs().DeleteJobs(ctx, ids)
+	_, _ = jobCol.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+
+	if uploader != nil {
+		prefix := "components/" + comp.Slug + "/" + models.PreviewVersion(ev.Number) + "/"
+		if _, err := uploader.DeletePrefix(ctx, prefix); err != nil {
+			fmt.Printf("WARNING: failed to purge %s: %v\n", prefix, err)
+		}
+	}
+
+	if token, err := githubapi.TokenForUser(ctx, comp.OwnerID); err == nil {
+		_ = githubapi.NewClient(token).UpsertIssueComment(ctx, comp.RepoLink.Owner, comp.RepoLink.Repo, ev.Number,
+			models.PreviewCommentMarker(comp.Slug),
+			fmt.Sprintf("**StoreHUBX preview for `%s`** was removed because this pull request was closed.", comp.Slug))
+	}
+
+	res.Skipped = fmt.Sprintf("preview removed (%d builds deleted)", len(jobs))
+	return res
+}
+
+func withStatus(filter bson.M, status models.BuildStatus) bson.M {
+	f := bson.M{"status": status}
+	for k, v := range filter {
+		f[k] = v
+	}
+	return f
+}
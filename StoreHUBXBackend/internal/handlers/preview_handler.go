@@ -7,16 +7,18 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/rishyym0927/storehubx/internal/db"
+	"github.com/rishyym0927/storehubx/internal/models"
 	"github.com/rishyym0927/storehubx/internal/utils"
 	"go.mongodb.org/mongo-driver/bson"
 )
 
 // GET /preview/:slug/:version -> 302 to public previewUrl
+// :version may also be a dist-tag, e.g. /preview/button/latest
 func RedirectPreview(c *fiber.Ctx) error {
 	// Debug information
 	log.Printf("Preview request received: URL=%s, Method=%s, Path=%s", c.BaseURL()+c.OriginalURL(), c.Method(), c.Path())
 
-	slug := c.Params("slug")
+	slug := slugParam(c)
 	ver := c.Params("version")
 	log.Printf("Preview params: slug=%s, version=%s", slug, ver)
 
@@ -28,19 +30,14 @@ func RedirectPreview(c *fiber.Ctx) error {
 	defer cancel()
 
 	colComp := db.Client.Database("storehub").Collection("components")
-	var comp struct {
-		ID any `bson:"_id"`
-	}
+	var comp models.Component
 	if err := colComp.FindOne(ctx, bson.M{"slug": slug}).Decode(&comp); err != nil {
 		return utils.Error(c, 404, "component not found")
 	}
 
-	colVer := db.Client.Database("storehub").Collection("component_versions")
-	var v struct {
-		PreviewURL string `bson:"previewUrl"`
-	}
-	if err := colVer.FindOne(ctx, bson.M{"componentId": comp.ID, "version": ver}).Decode(&v); err != nil {
-		return utils.Error(c, 404, "version not found")
+	v, ferr := resolveVersionRef(ctx, &comp, ver)
+	if ferr != nil {
+		return utils.Error(c, ferr.Code, ferr.Message)
 	}
 	if v.PreviewURL == "" {
 		return utils.Error(c, 404, "preview not available for this version")
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rishyym0927/storehubx/internal/db"
+	"github.com/rishyym0927/storehubx/internal/models"
+	"github.com/rishyym0927/storehubx/internal/semver"
+	"github.com/rishyym0927/storehubx/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// GET /components/:slug/resolve?range=^1.2.0  (public)
+// range may be a dist-tag ("latest", "next"), an exact version or an npm
+// range; it defaults to "latest".
+func ResolveVersion(c *fiber.Ctx) error {
+	slug := slugParam(c)
+	ref := strings.TrimSpace(c.Query("range"))
+	if ref == "" {
+		ref = models.DistTagLatest
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	compCol := db.Client.Database("storehub").Collection("components")
+	var comp models.Component
+	if err := compCol.FindOne(ctx, bson.M{"slug": slug}).Decode(&comp); err != nil {
+		return utils.Error(c, 404, "component not found")
+	}
+
+	ver, ferr := resolveRange(ctx, &comp, ref)
+	if ferr != nil {
+		return utils.Error(c, ferr.Code, ferr.Message)
+	}
+	for tag, v := range comp.DistTags {
+		if v == ver.Version {
+			ver.Tags = append(ver.Tags, tag)
+		}
+	}
+	sort.Strings(ver.Tags)
+	ver.Latest = comp.DistTags[models.DistTagLatest] == ver.Version
+
+	return utils.Success(c, fiber.Map{
+		"component":  comp.Slug,
+		"range":      ref,
+		"version":    ver,
+		"deprecated": comp.Deprecated,
+	})
+}
+
+// resolveRange picks the version a dist-tag, exact version or range names.
+// Only versions whose build finished are returned. Ranges also skip yanked
+// versions; exact versions are returned even when yanked.
+func resolveRange(ctx context.Context, comp *models.Component, ref string) (*models.ComponentVersion, *fiber.Error) {
+	if _, isTag := comp.DistTags[ref]; isTag || ref == models.DistTagLatest || semver.Valid(ref) {
+		return resolveVersionRef(ctx, comp, ref)
+	}
+
+	r, err := semver.ParseRange(ref)
+	if err != nil {
+		if distTagPattern.MatchString(ref) {
+			return nil, fiber.NewError(404, fmt.Sprintf("no dist-tag %q", ref))
+		}
+		return nil, fiber.NewError(400, err.Error())
+	}
+
+	verCol := db.Client.Database("storehub").Collection("component_versions")
+	versions, err := loadVersions(ctx, verCol, comp.ID)
+	if err != nil {
+		return nil, fiber.NewError(500, "database error")
+	}
+	for i, v := range versions { // highest first
+		if v.Yanked || !installable(v.BuildState) {
+			continue
+		}
+		if sv, err := semver.Parse(v.Version); err == nil && r.Contains(sv) {
+			return &versions[i], nil
+		}
+	}
+	return nil, fiber.NewError(404, fmt.Sprintf("no version of %s matches %s", comp.Slug, ref))
+}
+
+// installable reports whether a version's build has finished; rows from
+// before builds existed have no state and count as published.
+func installable(state models.BuildState) bool {
+	return state == models.VersionBuildReady || state == "" || state == models.VersionBuildNone
+}
@@ -0,0 +1,12 @@
+package handlers
+
+import "github.com/rishyym0927/storehubx/internal/storage"
+
+// uploader is the object store used by handlers that touch published artifacts
+// (e.g. purging a deleted component). It stays nil when the API runs without storage.
+var uploader storage.Uploader
+
+// SetUploader wires the storage backend used by the handlers.
+func SetUploader(u storage.Uploader) {
+	uploader = u
+}
@@ -3,38 +3,57 @@ package handlers
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/rishyym0927/storehubx/internal/db"
 	"github.com/rishyym0927/storehubx/internal/models"
+	"github.com/rishyym0927/storehubx/internal/semver"
 	"github.com/rishyym0927/storehubx/internal/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// POST /api/components/:slug/versions  (protected)
+// addVersionPayload is what a client may set on a new version. Build state,
+// source artifact, yank and deprecation fields are only ever set by the
+// worker and the owner's dedicated endpoints.
+type addVersionPayload struct {
+	Version   string `json:"version"`
+	CommitSHA string `json:"commitSha"`
+	Changelog string `json:"changelog"`
+	CodeURL   string `json:"codeUrl"`
+	Readme    string `json:"readme"`
+}
+
+// POST /api/components/:slug/versions  (protected, owner only)
 func AddVersion(c *fiber.Ctx) error {
-	componentSlug := c.Params("slug")
+	componentSlug := slugParam(c)
 
-	var version models.ComponentVersion
-	if err := c.BodyParser(&version); err != nil {
+	var body addVersionPayload
+	if err := c.BodyParser(&body); err != nil {
 		return utils.Error(c, 400, "invalid JSON body")
 	}
-	if strings.TrimSpace(version.Version) == "" {
+	version := models.ComponentVersion{
+		Version:   strings.TrimSpace(body.Version),
+		CommitSHA: strings.TrimSpace(body.CommitSHA),
+		Changelog: body.Changelog,
+		CodeURL:   strings.TrimSpace(body.CodeURL),
+		Readme:    strings.TrimSpace(body.Readme),
+	}
+	if version.Version == "" {
 		return utils.Error(c, 400, "version number required")
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	compCol := db.Client.Database("storehub").Collection("components")
-	var comp models.Component
-	if err := compCol.FindOne(ctx, bson.M{"slug": componentSlug}).Decode(&comp); err != nil {
-		return utils.Error(c, 404, "component not found")
+	uid, _ := c.Locals("user_id").(string)
+	comp, ferr := findOwnedComponent(ctx, componentSlug, uid)
+	if ferr != nil {
+		return utils.Error(c, ferr.Code, ferr.Message)
 	}
 
 	// Check if component is linked to a repo
@@ -43,7 +62,7 @@ func AddVersion(c *fiber.Ctx) error {
 	}
 
 	// If no commit SHA provided, use the one from component's repoLink
-	if strings.TrimSpace(version.CommitSHA) == "" {
+	if version.CommitSHA == "" {
 		version.CommitSHA = comp.RepoLink.Commit
 	}
 
@@ -53,6 +72,10 @@ func AddVersion(c *fiber.Ctx) error {
 
 	verCol := db.Client.Database("storehub").Collection("component_versions")
 
+	if ferr := checkNewVersion(ctx, verCol, comp.ID, version.Version); ferr != nil {
+		return utils.Error(c, ferr.Code, ferr.Message)
+	}
+
 	// Check if version already exists for this commit
 	var existingVersion models.ComponentVersion
 	err := verCol.FindOne(ctx, bson.M{
@@ -65,16 +88,15 @@ func AddVersion(c *fiber.Ctx) error {
 		return utils.Error(c, 409, fmt.Sprintf("version already exists for commit %s (version: %s)", version.CommitSHA[:7], existingVersion.Version))
 	}
 
-	uid, _ := c.Locals("user_id").(string)
 	version.ComponentID = comp.ID
 	version.CreatedBy = uid
 	version.CreatedAt = time.Now()
-	version.CodeURL = strings.TrimSpace(version.CodeURL)
-	version.PreviewURL = strings.TrimSpace(version.PreviewURL)
-	version.Readme = strings.TrimSpace(version.Readme)
 	version.BuildState = models.VersionBuildQueued
 
 	if _, err := verCol.InsertOne(ctx, version); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return utils.Error(c, 409, fmt.Sprintf("version %s already exists", version.Version))
+		}
 		return utils.Error(c, 500, "failed to insert version")
 	}
 
@@ -92,13 +114,14 @@ func AddVersion(c *fiber.Ctx) error {
 			Ref:    comp.RepoLink.Ref,
 			Commit: version.CommitSHA,
 		},
-		Logs:      []string{"enqueued"},
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
 	jobCol := db.Client.Database("storehub").Collection("build_jobs")
-	_, _ = jobCol.InsertOne(ctx, job)
+	if res, err := jobCol.InsertOne(ctx, job); err == nil {
+		logBuild(ctx, res.InsertedID.(primitive.ObjectID), "enqueued")
+	}
 
 	return utils.Success(c, fiber.Map{
 		"status":  "version added",
@@ -109,7 +132,7 @@ func AddVersion(c *fiber.Ctx) error {
 
 // GET /components/:slug/versions  (public)
 func GetComponentVersions(c *fiber.Ctx) error {
-	slug := c.Params("slug")
+	slug := slugParam(c)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -121,27 +144,27 @@ func GetComponentVersions(c *fiber.Ctx) error {
 	}
 
 	verCol := db.Client.Database("storehub").Collection("component_versions")
-	cursor, err := verCol.Find(ctx, bson.M{"componentId": comp.ID})
+	versions, err := loadVersions(ctx, verCol, comp.ID)
 	if err != nil {
 		return utils.Error(c, 500, "database error")
 	}
-	defer cursor.Close(ctx)
+	applyDistTags(versions, comp.DistTags)
 
-	// Pre-init to ensure JSON array, not null
-	versions := make([]models.ComponentVersion, 0)
-	if err := cursor.All(ctx, &versions); err != nil {
-		return utils.Error(c, 500, "failed to decode versions")
+	distTags := comp.DistTags
+	if distTags == nil {
+		distTags = map[string]string{}
 	}
-
 	return utils.Success(c, fiber.Map{
-		"versions": versions, // [] when empty
+		"versions":   versions, // [] when empty
+		"distTags":   distTags,
+		"deprecated": comp.Deprecated, // "" unless the whole component is deprecated
 	})
 }
 
 // POST /api/components/:slug/deploy  (protected)
 // Auto-deploy a new commit from linked repository
 func AutoDeploy(c *fiber.Ctx) error {
-	slug := c.Params("slug")
+	slug := slugParam(c)
 
 	type deployPayload struct {
 		CommitSHA string `json:"commitSha"`
@@ -161,88 +184,24 @@ func AutoDeploy(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// Find component
-	compCol := db.Client.Database("storehub").Collection("components")
-	var comp models.Component
-	if err := compCol.FindOne(ctx, bson.M{"slug": slug}).Decode(&comp); err != nil {
-		return utils.Error(c, 404, "component not found")
-	}
-
-	// Verify component is linked
-	if comp.RepoLink.Owner == "" || comp.RepoLink.Repo == "" {
-		return utils.Error(c, 400, "component is not linked to a repository")
-	}
-
-	verCol := db.Client.Database("storehub").Collection("component_versions")
-
-	// Check if version exists for this commit
-	var existingVersion models.ComponentVersion
-	err := verCol.FindOne(ctx, bson.M{
-		"componentId": comp.ID,
-		"commitSha":   payload.CommitSHA,
-	}).Decode(&existingVersion)
-
-	if err == nil {
-		return utils.Error(c, 409, fmt.Sprintf("version already exists for this commit: %s", existingVersion.Version))
-	}
-
-	// Auto-generate version number if not provided
-	versionNumber := payload.Version
-	if versionNumber == "" {
-		versionNumber = generateNextVersion(ctx, verCol, comp.ID)
-	}
-
+	// Only the owner may publish a version of the component
 	uid, _ := c.Locals("user_id").(string)
-
-	// Create new version
-	newVersion := models.ComponentVersion{
-		ComponentID: comp.ID,
-		Version:     versionNumber,
-		Changelog:   payload.Changelog,
-		CommitSHA:   payload.CommitSHA,
-		BuildState:  models.VersionBuildQueued,
-		CreatedBy:   uid,
-		CreatedAt:   time.Now(),
-	}
-
-	if newVersion.Changelog == "" {
-		newVersion.Changelog = fmt.Sprintf("Auto-deployed from commit %s", payload.CommitSHA[:7])
-	}
-
-	insertResult, err := verCol.InsertOne(ctx, newVersion)
-	if err != nil {
-		return utils.Error(c, 500, "failed to create version")
+	comp, ferr := findOwnedComponent(ctx, slug, uid)
+	if ferr != nil {
+		return utils.Error(c, ferr.Code, ferr.Message)
 	}
 
-	newVersion.ID = insertResult.InsertedID.(primitive.ObjectID)
-
-	// Create build job
-	job := models.BuildJob{
-		ComponentID: comp.ID,
-		Component:   slug,
-		Version:     versionNumber,
-		Status:      models.BuildQueued,
-		OwnerID:     uid,
-		Repo: models.BuildRepo{
-			Owner:  comp.RepoLink.Owner,
-			Repo:   comp.RepoLink.Repo,
-			Path:   comp.RepoLink.Path,
-			Ref:    comp.RepoLink.Ref,
-			Commit: payload.CommitSHA,
-		},
-		Logs:      []string{"enqueued - auto-deploy"},
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	}
-
-	jobCol := db.Client.Database("storehub").Collection("build_jobs")
-	jobRes, err := jobCol.InsertOne(ctx, job)
-	if err != nil {
-		return utils.Error(c, 500, "failed to create build job")
+	newVersion, jobID, ferr := deployCommit(ctx, comp, deployRequest{
+		CommitSHA: payload.CommitSHA,
+		Version:   payload.Version,
+		Changelog: payload.Changelog,
+		UserID:    uid,
+		Source:    "auto-deploy",
+	})
+	if ferr != nil {
+		return utils.Error(c, ferr.Code, ferr.Message)
 	}
 
-	jobID, _ := jobRes.InsertedID.(primitive.ObjectID)
-
 	return utils.Success(c, fiber.Map{
 		"version": newVersion,
 		"jobId":   jobID.Hex(),
@@ -250,31 +209,110 @@ func AutoDeploy(c *fiber.Ctx) error {
 	})
 }
 
-// Helper function to generate next semantic version
+// generateNextVersion bumps the patch of the component's highest version
+// ("1.2.3" -> "1.2.4", "1.3.0-rc.1" -> "1.3.0"), or starts at 1.0.0.
 func generateNextVersion(ctx context.Context, verCol *mongo.Collection, componentID primitive.ObjectID) string {
-	// Get latest version sorted by creation date
-	opts := options.Find().SetSort(bson.M{"createdAt": -1}).SetLimit(1)
-	cursor, err := verCol.Find(ctx, bson.M{"componentId": componentID}, opts)
+	versions, err := loadVersions(ctx, verCol, componentID)
 	if err != nil {
 		return "1.0.0"
 	}
+	if highest, ok := highestVersion(versions); ok {
+		return highest.NextPatch().String()
+	}
+	return "1.0.0"
+}
+
+// loadVersions returns the component's versions, highest precedence first.
+func loadVersions(ctx context.Context, verCol *mongo.Collection, componentID primitive.ObjectID) ([]models.ComponentVersion, error) {
+	cursor, err := verCol.Find(ctx, bson.M{"componentId": componentID})
+	if err != nil {
+		return nil, err
+	}
 	defer cursor.Close(ctx)
 
-	var versions []models.ComponentVersion
-	if err := cursor.All(ctx, &versions); err != nil || len(versions) == 0 {
-		return "1.0.0"
+	// Pre-init to ensure JSON array, not null
+	versions := make([]models.ComponentVersion, 0)
+	if err := cursor.All(ctx, &versions); err != nil {
+		return nil, err
 	}
+	sortVersions(versions)
+	return versions, nil
+}
 
-	// Parse latest version and increment patch
-	latestVersion := versions[0].Version
-	// Simple increment: if it's "1.2.3", make it "1.2.4"
+// sortVersions orders versions by semver precedence, highest first. Rows
+// that predate validation and don't parse go last, newest first.
+func sortVersions(versions []models.ComponentVersion) {
+	parsed := make(map[string]semver.Version, len(versions))
+	for _, v := range versions {
+		if sv, err := semver.Parse(v.Version); err == nil {
+			parsed[v.Version] = sv
+		}
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		a, aok := parsed[versions[i].Version]
+		b, bok := parsed[versions[j].Version]
+		switch {
+		case aok && bok:
+			return semver.Compare(a, b) > 0
+		case aok != bok:
+			return aok
+		}
+		return versions[i].CreatedAt.After(versions[j].CreatedAt)
+	})
+}
 
-	var major, minor, patch int
-	_, err = fmt.Sscanf(latestVersion, "%d.%d.%d", &major, &minor, &patch)
-	if err != nil {
-		// If parsing fails, start with 1.0.0
-		return "1.0.0"
+// markLatest flags the highest stable version as latest, or the highest
+// prerelease when nothing stable exists. Yanked versions and versions whose
+// build has not finished are never latest.
+// versions must be sorted.
+func markLatest(versions []models.ComponentVersion) {
+	pick := -1
+	for i, v := range versions {
+		sv, err := semver.Parse(v.Version)
+		if err != nil || v.Yanked || !installable(v.BuildState) {
+			continue
+		}
+		if !sv.IsPrerelease() {
+			pick = i
+			break
+		}
+		if pick < 0 {
+			pick = i
+		}
 	}
+	if pick >= 0 {
+		versions[pick].Latest = true
+	}
+}
+
+func highestVersion(versions []models.ComponentVersion) (semver.Version, bool) {
+	for _, v := range versions {
+		if sv, err := semver.Parse(v.Version); err == nil {
+			return sv, true // sorted, so the first valid one is the highest
+		}
+	}
+	return semver.Version{}, false
+}
 
-	return fmt.Sprintf("%d.%d.%d", major, minor, patch+1)
+// checkNewVersion validates a version about to be created: it must be valid
+// SemVer, not exist yet (build metadata aside) and be higher than every
+// existing version of the component.
+func checkNewVersion(ctx context.Context, verCol *mongo.Collection, componentID primitive.ObjectID, version string) *fiber.Error {
+	sv, err := semver.Parse(version)
+	if err != nil {
+		return fiber.NewError(400, err.Error())
+	}
+	versions, err := loadVersions(ctx, verCol, componentID)
+	if err != nil {
+		return fiber.NewError(500, "failed to load versions")
+	}
+	for _, v := range versions {
+		if other, err := semver.Parse(v.Version); err == nil && semver.Compare(sv, other) == 0 {
+			return fiber.NewError(409, fmt.Sprintf("version %s already exists", v.Version))
+		}
+	}
+	if highest, ok := highestVersion(versions); ok && !highest.LessThan(sv) {
+		return fiber.NewError(409, fmt.Sprintf("version %s must be greater than the current highest version %s", version, highest))
+	}
+	return nil
 }
@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rishyym0927/storehubx/internal/db"
+	"github.com/rishyym0927/storehubx/internal/models"
+	"github.com/rishyym0927/storehubx/internal/semver"
+	"github.com/rishyym0927/storehubx/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const maxNoticeLength = 1024
+
+type noticePayload struct {
+	Reason  string `json:"reason"`  // yank
+	Message string `json:"message"` // deprecate
+}
+
+// POST /api/components/:slug/versions/:version/yank  (protected, owner only)
+// Body (optional): { "reason": "breaks SSR" }
+func YankVersion(c *fiber.Ctx) error {
+	var body noticePayload
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&body); err != nil {
+			return utils.Error(c, 400, "invalid JSON body")
+		}
+	}
+	reason := strings.TrimSpace(body.Reason)
+	if utf8.RuneCountInString(reason) > maxNoticeLength {
+		return utils.Error(c, 400, "reason is too long")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	comp, ver, ferr := findOwnedVersion(ctx, c)
+	if ferr != nil {
+		return utils.Error(c, ferr.Code, ferr.Message)
+	}
+
+	now := time.Now()
+	verCol := db.Client.Database("storehub").Collection("component_versions")
+	if _, err := verCol.UpdateByID(ctx, ver.ID, bson.M{"$set": bson.M{
+		"yanked":     true,
+		"yankReason": reason,
+		"yankedAt":   now,
+	}}); err != nil {
+		return utils.Error(c, 500, "failed to yank version")
+	}
+	ver.Yanked, ver.YankReason, ver.YankedAt = true, reason, &now
+
+	distTags, ferr := untagYanked(ctx, comp, ver.Version)
+	if ferr != nil {
+		return utils.Error(c, ferr.Code, ferr.Message)
+	}
+
+	return utils.Success(c, fiber.Map{
+		"version":  ver,
+		"distTags": distTags,
+	})
+}
+
+// DELETE /api/components/:slug/versions/:version/yank  (protected, owner only)
+func UnyankVersion(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	comp, ver, ferr := findOwnedVersion(ctx, c)
+	if ferr != nil {
+		return utils.Error(c, ferr.Code, ferr.Message)
+	}
+
+	verCol := db.Client.Database("storehub").Collection("component_versions")
+	if _, err := verCol.UpdateByID(ctx, ver.ID, bson.M{"$unset": bson.M{
+		"yanked":     "",
+		"yankReason": "",
+		"yankedAt":   "",
+	}}); err != nil {
+		return utils.Error(c, 500, "failed to unyank version")
+	}
+	ver.Yanked, ver.YankReason, ver.YankedAt = false, "", nil
+
+	// A restored stable release takes latest back if it is the highest again.
+	distTags := comp.DistTags
+	if sv, err := semver.Parse(ver.Version); err == nil && !sv.IsPrerelease() && ver.BuildState == models.VersionBuildReady {
+		cur, err := semver.Parse(comp.DistTags[models.DistTagLatest])
+		if err != nil || cur.LessThan(sv) {
+			if distTags, ferr = setLatestTag(ctx, comp, ver.Version); ferr != nil {
+				return utils.Error(c, ferr.Code, ferr.Message)
+			}
+		}
+	}
+
+	return utils.Success(c, fiber.Map{
+		"version":  ver,
+		"distTags": distTags,
+	})
+}
+
+// PUT /api/components/:slug/deprecation  (protected, owner only)
+// Body: { "message": "Use @acme/button instead" }
+func DeprecateComponent(c *fiber.Ctx) error {
+	msg, ferr := deprecationMessage(c)
+	if ferr != nil {
+		return utils.Error(c, ferr.Code, ferr.Message)
+	}
+	return setComponentDeprecation(c, msg)
+}
+
+// DELETE /api/components/:slug/deprecation  (protected, owner only)
+func UndeprecateComponent(c *fiber.Ctx) error {
+	return setComponentDeprecation(c, "")
+}
+
+// PUT /api/components/:slug/versions/:version/deprecation  (protected, owner only)
+// Body: { "message": "Critical bug, upgrade to 1.2.1" }
+func DeprecateVersion(c *fiber.Ctx) error {
+	msg, ferr := deprecationMessage(c)
+	if ferr != nil {
+		return utils.Error(c, ferr.Code, ferr.Message)
+	}
+	return setVersionDeprecation(c, msg)
+}
+
+// DELETE /api/components/:slug/versions/:version/deprecation  (protected, owner only)
+func UndeprecateVersion(c *fiber.Ctx) error {
+	return setVersionDeprecation(c, "")
+}
+
+func deprecationMessage(c *fiber.Ctx) (string, *fiber.Error) {
+	var body noticePayload
+	if err := c.BodyParser(&body); err != nil {
+		return "", fiber.NewError(400, "invalid JSON body")
+	}
+	msg := strings.TrimSpace(body.Message)
+	if msg == "" {
+		return "", fiber.NewError(400, "message is required")
+	}
+	if utf8.RuneCountInString(msg) > maxNoticeLength {
+		return "", fiber.NewError(400, "message is too long")
+	}
+	return msg, nil
+}
+
+func setComponentDeprecation(c *fiber.Ctx, msg string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	uid, _ := c.Locals("user_id").(string)
+	comp, ferr := findOwnedComponent(ctx, slugParam(c), uid)
+	if ferr != nil {
+		return utils.Error(c, ferr.Code, ferr.Message)
+	}
+
+	col := db.Client.Database("storehub").Collection("components")
+	if _, err := col.UpdateByID(ctx, comp.ID, bson.M{"$set": bson.M{
+		"deprecated": msg, // "" clears it
+		"updatedAt":  time.Now(),
+	}}); err != nil {
+		return utils.Error(c, 500, "failed to update component")
+	}
+	comp.Deprecated = msg
+
+	return utils.Success(c, fiber.Map{
+		"component": comp,
+	})
+}
+
+func setVersionDeprecation(c *fiber.Ctx, msg string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, ver, ferr := findOwnedVersion(ctx, c)
+	if ferr != nil {
+		return utils.Error(c, ferr.Code, ferr.Message)
+	}
+
+	verCol := db.Client.Database("storehub").Collection("component_versions")
+	if _, err := verCol.UpdateByID(ctx, ver.ID, bson.M{"$set": bson.M{"deprecated": msg}}); err != nil {
+		return utils.Error(c, 500, "failed to update version")
+	}
+	ver.Deprecated = msg
+
+	return utils.Success(c, fiber.Map{
+		"version": ver,
+	})
+}
+
+// findOwnedVersion loads :slug (owned by the caller) and its exact :version.
+func findOwnedVersion(ctx context.Context, c *fiber.Ctx) (*models.Component, *models.ComponentVersion, *fiber.Error) {
+	uid, _ := c.Locals("user_id").(string)
+	comp, ferr := findOwnedComponent(ctx, slugParam(c), uid)
+	if ferr != nil {
+		return nil, nil, ferr
+	}
+
+	verCol := db.Client.Database("storehub").Collection("component_versions")
+	var ver models.ComponentVersion
+	if err := verCol.FindOne(ctx, bson.M{"componentId": comp.ID, "version": c.Params("version")}).Decode(&ver); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil, fiber.NewError(404, "version not found")
+		}
+		return nil, nil, fiber.NewError(500, "database error")
+	}
+	return comp, &ver, nil
+}
+
+// untagYanked removes every dist-tag pointing at a yanked version. latest
+// moves back to the highest remaining stable version, or is cleared so that
+// resolution falls back to computing it.
+func untagYanked(ctx context.Context, comp *models.Component, version string) (map[string]string, *fiber.Error) {
+	unset := bson.M{}
+	set := bson.M{}
+	for tag, v := range comp.DistTags {
+		if v == version {
+			unset["distTags."+tag] = ""
+		}
+	}
+	if len(unset) == 0 {
+		return comp.DistTags, nil
+	}
+
+	if _, ok := unset["distTags."+models.DistTagLatest]; ok {
+		verCol := db.Client.Database("storehub").Collection("component_versions")
+		versions, err := loadVersions(ctx, verCol, comp.ID)
+		if err != nil {
+			return nil, fiber.NewError(500, "database error")
+		}
+		for _, v := range versions {
+			sv, err := semver.Parse(v.Version)
+			if err != nil || v.Yanked || sv.IsPrerelease() || v.BuildState != models.VersionBuildReady {
+				continue
+			}
+			delete(unset, "distTags."+models.DistTagLatest)
+			set["distTags."+models.DistTagLatest] = v.Version
+			break
+		}
+	}
+
+	set["updatedAt"] = time.Now()
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	col := db.Client.Database("storehub").Collection("components")
+	if _, err := col.UpdateByID(ctx, comp.ID, update); err != nil {
+		return nil, fiber.NewError(500, "failed to update dist-tags")
+	}
+
+	for key := range unset {
+		delete(comp.DistTags, strings.TrimPrefix(key, "distTags."))
+	}
+	if v, ok := set["distTags."+models.DistTagLatest].(string); ok {
+		comp.DistTags[models.DistTagLatest] = v
+	}
+	return comp.DistTags, nil
+}
+
+func setLatestTag(ctx context.Context, comp *models.Component, version string) (map[string]string, *fiber.Error) {
+	col := db.Client.Database("storehub").Collection("components")
+	if _, err := col.UpdateByID(ctx, comp.ID, bson.M{"$set": bson.M{
+		"distTags." + models.DistTagLatest: version,
+		"updatedAt":                        time.Now(),
+	}}); err != nil {
+		return nil, fiber.NewError(500, "failed to update dist-tags")
+	}
+	if comp.DistTags == nil {
+		comp.DistTags = map[string]string{}
+	}
+	comp.DistTags[models.DistTagLatest] = version
+	return comp.DistTags, nil
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/rishyym0927/storehubx/internal/auth"
 	"github.com/rishyym0927/storehubx/internal/config"
 ) // JWTProtected verifies JWT and attaches claims to context
 func JWTProtected(c *fiber.Ctx) error {
@@ -30,6 +31,11 @@ func JWTProtected(c *fiber.Ctx) error {
 	}
 
 	if claims, ok := token.Claims.(jwt.MapClaims); ok {
+		// purpose-bound tokens (log stream tokens) are not session tokens
+		if _, scoped := claims["purpose"]; scoped {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Token is not a session token"})
+		}
+
 		// Debug log the claims
 		fmt.Printf("DEBUG JWT Claims: %+v\n", claims)
 
@@ -49,4 +55,23 @@ func JWTProtected(c *fiber.Ctx) error {
 	return c.Next()
 }
 
+// BuildStreamAuth guards the build log stream. Clients that can send headers
+// use the Authorization header as everywhere else; EventSource passes a
+// stream token from POST /api/builds/:id/logs/stream-token as ?token=.
+func BuildStreamAuth(c *fiber.Ctx) error {
+	tokenStr := c.Query("token")
+	if c.Get("Authorization") != "" || tokenStr == "" {
+		return JWTProtected(c)
+	}
+	claims, err := auth.VerifyStreamToken(tokenStr, c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid stream token: " + err.Error()})
+	}
+	userID, _ := claims["user_id"].(string)
+	email, _ := claims["email"].(string)
+	c.Locals("user_id", userID)
+	c.Locals("email", email)
+	return c.Next()
+}
+
 //Middleware verifies JWT, extracts claims, and attaches them to c.Locals() for downstream handlers.
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"fmt"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -13,10 +14,45 @@ const (
 	BuildRunning  BuildStatus = "running"
 	BuildSuccess  BuildStatus = "success"
 	BuildError    BuildStatus = "error"
+	BuildCanceled BuildStatus = "canceled"
 )
 
+// Build pipeline steps, in the order they run.
+const (
+	StepDownload = "download"
+	StepExtract  = "extract"
+	StepSnapshot = "snapshot"
+	StepInstall  = "install"
+	StepBuild    = "build"
+	StepRewrite  = "rewrite"
+	StepUpload   = "upload"
+)
+
+var BuildStepNames = []string{StepDownload, StepExtract, StepSnapshot, StepInstall, StepBuild, StepRewrite, StepUpload}
+
+type StepStatus string
+
+const (
+	StepPending  StepStatus = "pending"
+	StepRunning  StepStatus = "running"
+	StepSuccess  StepStatus = "success"
+	StepError    StepStatus = "error"
+	StepSkipped  StepStatus = "skipped"
+	StepCanceled StepStatus = "canceled"
+)
+
+type BuildStep struct {
+	Name       string     `bson:"name" json:"name"`
+	Status     StepStatus `bson:"status" json:"status"`
+	StartedAt  *time.Time `bson:"startedAt,omitempty" json:"startedAt,omitempty"`
+	EndedAt    *time.Time `bson:"endedAt,omitempty" json:"endedAt,omitempty"`
+	DurationMs int64      `bson:"durationMs,omitempty" json:"durationMs,omitempty"`
+	Error      string     `bson:"error,omitempty" json:"error,omitempty"`
+}
+
 type BuildArtifact struct {
-	BundleURL string `bson:"bundleUrl" json:"bundleUrl"` // public URL (S3/R2/MinIO)
+	BundleURL string `bson:"bundleUrl,omitempty" json:"bundleUrl,omitempty"` // public URL (S3/R2/MinIO)
+	LogURL    string `bson:"logUrl,omitempty" json:"logUrl,omitempty"`       // full build log, uploaded when the job ends
 }
 
 type BuildRepo struct {
@@ -27,16 +63,57 @@ type BuildRepo struct {
 	Commit string `bson:"commit" json:"commit"` // optional pinned sha
 }
 
+// PullRequestRef marks a preview build of an open pull request. Preview jobs
+// publish under components/<slug>/pr-<n>/ and never touch component_versions.
+type PullRequestRef struct {
+	Number  int    `bson:"number" json:"number"`
+	Title   string `bson:"title,omitempty" json:"title,omitempty"`
+	URL     string `bson:"url,omitempty" json:"url,omitempty"`
+	HeadRef string `bson:"headRef,omitempty" json:"headRef,omitempty"` // source branch
+}
+
+// PreviewVersion is the version label (and storage folder) of a pull request preview.
+func PreviewVersion(number int) string {
+	return fmt.Sprintf("pr-%d", number)
+}
+
+// PreviewCommentMarker tags the single pull request comment kept up to date
+// for one component's preview.
+func PreviewCommentMarker(slug string) string {
+	return "<!-- storehubx-preview:" + slug + " -->"
+}
+
 type BuildJob struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	ComponentID primitive.ObjectID `bson:"componentId" json:"componentId"`
 	Component   string             `bson:"component" json:"component"`   // slug (for convenience)
 	Version     string             `bson:"version" json:"version"`       // e.g., "0.1.0"
-	Status      BuildStatus        `bson:"status" json:"status"`         // queued|running|success|error
+	Status      BuildStatus        `bson:"status" json:"status"`         // queued|running|success|error|canceled
 	OwnerID     string             `bson:"ownerId" json:"ownerId"`       // from JWT (providerId)
 	Repo        BuildRepo          `bson:"repo" json:"repo"`
 	Artifacts   *BuildArtifact     `bson:"artifacts,omitempty" json:"artifacts,omitempty"`
-	Logs        []string           `bson:"logs,omitempty" json:"logs,omitempty"`
+	Logs        []string           `bson:"logs,omitempty" json:"logs,omitempty"` // legacy: jobs now log to the build_logs collection
+	Steps       []BuildStep        `bson:"steps,omitempty" json:"steps,omitempty"` // pipeline progress, reset each time a worker claims the job
+
+	// Lease held by the worker currently running the job; renewed by heartbeats.
+	LeaseOwner     string     `bson:"leaseOwner,omitempty" json:"leaseOwner,omitempty"`
+	LeaseExpiresAt *time.Time `bson:"leaseExpiresAt,omitempty" json:"leaseExpiresAt,omitempty"`
+	HeartbeatAt    *time.Time `bson:"heartbeatAt,omitempty" json:"heartbeatAt,omitempty"`
+	Attempts       int        `bson:"attempts" json:"attempts"`                           // times a worker claimed the job
+	MaxAttempts    int        `bson:"maxAttempts,omitempty" json:"maxAttempts,omitempty"` // defaulted by the worker on first claim
+
+	// Set when the owner cancels a running job; the worker notices on its next heartbeat.
+	CancelRequested bool `bson:"cancelRequested,omitempty" json:"cancelRequested,omitempty"`
+
+	// Retry chain: a retry points at the failed job it was cloned from, and
+	// the failed job at its retry once one is taken.
+	ParentJobID *primitive.ObjectID `bson:"parentJobId,omitempty" json:"parentJobId,omitempty"`
+	RetriedAs   *primitive.ObjectID `bson:"retriedAs,omitempty" json:"retriedAs,omitempty"`
+	RetryNumber int                 `bson:"retryNumber" json:"retryNumber"`                   // 0 for the original build, n for the nth retry
+	ClearCache  bool                `bson:"clearCache,omitempty" json:"clearCache,omitempty"` // wipe the dependency cache before installing
+
+	// Set on pull request preview builds; Version is then PreviewVersion(n).
+	PullRequest *PullRequestRef `bson:"pullRequest,omitempty" json:"pullRequest,omitempty"`
 
 	CreatedAt time.Time  `bson:"createdAt" json:"createdAt"`
 	UpdatedAt time.Time  `bson:"updatedAt" json:"updatedAt"`
@@ -10,12 +10,15 @@ type Component struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	Name        string             `bson:"name" json:"name"`
 	Slug        string             `bson:"slug" json:"slug"`
+	Scope       string             `bson:"scope,omitempty" json:"scope,omitempty"` // owner namespace for "@scope/name" slugs
 	Description string             `bson:"description" json:"description"`
 	Frameworks  []string           `bson:"frameworks" json:"frameworks"`
 	Tags        []string           `bson:"tags" json:"tags"`
 	License     string             `bson:"license" json:"license"`
 	OwnerID     string             `bson:"ownerId" json:"ownerId"`
 	RepoLink    RepoLink           `bson:"repoLink" json:"repoLink"`
+	DistTags    map[string]string  `bson:"distTags,omitempty" json:"distTags,omitempty"` // tag -> version, e.g. "latest": "1.2.0"
+	Deprecated  string             `bson:"deprecated,omitempty" json:"deprecated,omitempty"` // message shown on install when set
 	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
 	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`
 	// add version  now from version model
@@ -23,10 +26,20 @@ type Component struct {
 
 }
 
+// DistTagLatest is the tag installs resolve to when no version is given. The
+// worker moves it forward on successful stable builds; it cannot be deleted.
+const DistTagLatest = "latest"
+
 type RepoLink struct {
 	Owner  string `bson:"owner" json:"owner"`
 	Repo   string `bson:"repo" json:"repo"`
 	Path   string `bson:"path" json:"path"`     // folder where component lives
 	Ref    string `bson:"ref" json:"ref"`       // branch/tag
 	Commit string `bson:"commit" json:"commit"` // optional pinned sha
+
+	// Release mode: versions come from semver tags and GitHub releases
+	// instead of branch pushes. TagPrefix selects this component's tags in a
+	// monorepo (e.g. "button@" matches "button@v1.2.0").
+	TagReleases bool   `bson:"tagReleases,omitempty" json:"tagReleases,omitempty"`
+	TagPrefix   string `bson:"tagPrefix,omitempty" json:"tagPrefix,omitempty"`
 }
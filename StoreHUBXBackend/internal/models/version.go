@@ -9,13 +9,25 @@ import (
 type BuildState string
 
 const (
-	VersionBuildNone    BuildState = "none"
-	VersionBuildQueued  BuildState = "queued"
-	VersionBuildRunning BuildState = "running"
-	VersionBuildReady   BuildState = "ready"
-	VersionBuildError   BuildState = "error"
+	VersionBuildNone     BuildState = "none"
+	VersionBuildQueued   BuildState = "queued"
+	VersionBuildRunning  BuildState = "running"
+	VersionBuildReady    BuildState = "ready"
+	VersionBuildError    BuildState = "error"
+	VersionBuildCanceled BuildState = "canceled"
 )
 
+// SourceArtifact points at the tarball of the component folder that installs
+// deliver, and at its file manifest (see internal/artifact).
+type SourceArtifact struct {
+	TarballURL  string `bson:"tarballUrl" json:"tarballUrl"`
+	ManifestURL string `bson:"manifestUrl" json:"manifestUrl"`
+	Integrity   string `bson:"integrity" json:"integrity"` // "sha256-<base64>" of the tarball
+	Size        int64  `bson:"size" json:"size"`           // tarball bytes
+	Files       int    `bson:"files" json:"files"`
+	Commit      string `bson:"commit,omitempty" json:"commit,omitempty"`
+}
+
 type ComponentVersion struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	ComponentID primitive.ObjectID `bson:"componentId" json:"componentId"`
@@ -28,9 +40,27 @@ type ComponentVersion struct {
 	PreviewURL string     `bson:"previewUrl,omitempty" json:"previewUrl,omitempty"`
 	BuildState BuildState `bson:"buildState,omitempty" json:"buildState,omitempty"`
 
+	// Installable source snapshot, set by the worker once the build succeeds.
+	Source *SourceArtifact `bson:"source,omitempty" json:"source,omitempty"`
+
 	// Commit SHA for tracking unique commits
 	CommitSHA string `bson:"commitSha" json:"commitSha"`
 
+	// Withdrawn by the owner: skipped by dist-tags and "latest" resolution
+	// but still served when asked for by exact version.
+	Yanked     bool       `bson:"yanked,omitempty" json:"yanked,omitempty"`
+	YankReason string     `bson:"yankReason,omitempty" json:"yankReason,omitempty"`
+	YankedAt   *time.Time `bson:"yankedAt,omitempty" json:"yankedAt,omitempty"`
+
+	// Deprecation message shown to anyone installing this version.
+	Deprecated string `bson:"deprecated,omitempty" json:"deprecated,omitempty"`
+
 	CreatedBy string    `bson:"createdBy" json:"createdBy"`
 	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+
+	// Computed when listing (not stored): the version the "latest" dist-tag
+	// points at, falling back to the highest stable version, and every
+	// dist-tag that points at this version.
+	Latest bool     `bson:"-" json:"latest"`
+	Tags   []string `bson:"-" json:"tags,omitempty"`
 }
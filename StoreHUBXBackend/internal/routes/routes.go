@@ -1,11 +1,14 @@
 package routes
 
 import (
+	"strings"
+
 	"github.com/gofiber/fiber/v2"
 
 	"github.com/rishyym0927/storehubx/internal/auth"
 	"github.com/rishyym0927/storehubx/internal/handlers"
 	"github.com/rishyym0927/storehubx/internal/middleware"
+	"github.com/rishyym0927/storehubx/internal/storage"
 
 	// NOTE: ensure your handlers file is in: internal/github/handlers.go
 	// and its package is: package githubapi
@@ -27,17 +30,36 @@ func RegisterRoutes(app *fiber.App) {
 	app.Get("/components", handlers.GetAllComponents)
 	app.Get("/components/:slug", handlers.GetComponent)
 	app.Get("/components/:slug/versions", handlers.GetComponentVersions)
+	app.Get("/components/:slug/resolve", handlers.ResolveVersion)
 
 	// Preview (public access)
 	app.Get("/preview/:slug/:version", handlers.RedirectPreview)
 
+	// GitHub webhooks (authenticated by signature, not JWT)
+	app.Post("/webhooks/github", handlers.GitHubWebhook)
+
+	// Build log stream: registered ahead of the /api group so browsers'
+	// EventSource, which cannot send headers, can authenticate with a stream
+	// token in ?token= (BuildStreamAuth still accepts the header).
+	app.Get("/api/builds/:id/logs/stream", middleware.BuildStreamAuth, handlers.StreamBuildLogs)
+
 	// ---------- Protected (JWT) ----------
 	// Use the middleware function itself, not a type
 	api := app.Group("/api", middleware.JWTProtected)
 
 	// Components (writes)
 	api.Post("/components", handlers.CreateComponent)
+	api.Patch("/components/:slug", handlers.UpdateComponent)
+	api.Delete("/components/:slug", handlers.DeleteComponent)
 	api.Post("/components/:slug/versions", handlers.AddVersion)
+	api.Put("/components/:slug/tags/:tag", handlers.SetDistTag)
+	api.Delete("/components/:slug/tags/:tag", handlers.DeleteDistTag)
+	api.Put("/components/:slug/deprecation", handlers.DeprecateComponent)
+	api.Delete("/components/:slug/deprecation", handlers.UndeprecateComponent)
+	api.Post("/components/:slug/versions/:version/yank", handlers.YankVersion)
+	api.Delete("/components/:slug/versions/:version/yank", handlers.UnyankVersion)
+	api.Put("/components/:slug/versions/:version/deprecation", handlers.DeprecateVersion)
+	api.Delete("/components/:slug/versions/:version/deprecation", handlers.UndeprecateVersion)
 
 	// Link a component to a GitHub repo/folder (Phase 4.3)
 	api.Post("/components/:slug/link", handlers.LinkComponentRepo)
@@ -48,6 +70,11 @@ func RegisterRoutes(app *fiber.App) {
 	//phase 4.4
 	api.Post("/components/:slug/versions/:version/build", handlers.EnqueueBuild)
 	api.Get("/builds/:id", handlers.GetBuild)
+	api.Get("/builds/:id/logs", handlers.GetBuildLogs)
+	api.Post("/builds/:id/logs/stream-token", handlers.IssueLogStreamToken)
+	api.Get("/builds/:id/logs/download", handlers.DownloadBuildLogs)
+	api.Post("/builds/:id/cancel", handlers.CancelBuild)
+	api.Post("/builds/:id/retry", handlers.RetryBuild)
 	api.Get("/components/:slug/versions/:version/builds", handlers.ListBuildsForVersion)
 
 	// Authenticated profile
@@ -61,3 +88,21 @@ func RegisterRoutes(app *fiber.App) {
 	gh.Get("/contents", githubapi.GetRepoContents)
 	gh.Get("/branches", githubapi.GetBranch)
 }
+
+// RegisterStatic serves a filesystem storage root on /static, where
+// FSUploader's public URLs point, with the content types the uploader
+// recorded. Its metadata sidecars are not served.
+func RegisterStatic(app *fiber.App, u *storage.FSUploader) {
+	app.Static("/static", u.Root(), fiber.Static{
+		ByteRange: true,
+		Next: func(c *fiber.Ctx) bool {
+			return strings.HasPrefix(strings.TrimPrefix(c.Path(), "/static/"), ".")
+		},
+		ModifyResponse: func(c *fiber.Ctx) error {
+			if c.Response().StatusCode() == fiber.StatusOK {
+				c.Set(fiber.HeaderContentType, u.ContentType(strings.TrimPrefix(c.Path(), "/static/")))
+			}
+			return nil
+		},
+	})
+}
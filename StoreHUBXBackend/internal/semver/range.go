@@ -0,0 +1,338 @@
+package semver
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidRange = errors.New("invalid version range")
+
+// Range is a set of version constraints in npm syntax: comparator sets
+// joined by "||", each a space-separated list of comparators that must all
+// hold. Supported forms are exact versions, "=", ">", ">=", "<", "<=",
+// caret ("^1.2.3"), tilde ("~1.2.3"), x-ranges ("1.x", "1.2.*", "*") and
+// hyphen ranges ("1.2.3 - 2.3").
+type Range struct {
+	sets [][]comparator
+}
+
+type comparator struct {
+	op string // "=", ">", ">=", "<" or "<="
+	v  Version
+}
+
+// partial is a possibly incomplete version from a range: n is the number of
+// leading numbers given before the first wildcard or the end.
+type partial struct {
+	nums [3]uint64
+	n    int
+	pre  []string
+}
+
+// ParseRange parses an npm-style range. An empty string or "*" matches every
+// release.
+func ParseRange(s string) (Range, error) {
+	var r Range
+	for _, raw := range strings.Split(s, "||") {
+		set, err := parseSet(strings.TrimSpace(raw))
+		if err != nil {
+			return Range{}, fmt.Errorf("%w %q: %v", ErrInvalidRange, s, err)
+		}
+		r.sets = append(r.sets, set)
+	}
+	return r, nil
+}
+
+// Contains reports whether v satisfies the range. As in npm, a prerelease
+// only matches when some comparator of the satisfied set names a prerelease
+// of the same MAJOR.MINOR.PATCH, so "^1.2.0" never picks "1.3.0-beta.1"
+// but ">=1.3.0-beta.0 <2" does.
+func (r Range) Contains(v Version) bool {
+	for _, set := range r.sets {
+		if setContains(set, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// MaxSatisfying returns the highest version in vs that the range contains.
+func (r Range) MaxSatisfying(vs []Version) (Version, bool) {
+	var best Version
+	found := false
+	for _, v := range vs {
+		if r.Contains(v) && (!found || best.LessThan(v)) {
+			best, found = v, true
+		}
+	}
+	return best, found
+}
+
+// String renders the range in its normalized comparator form, e.g.
+// "^1.2.3" becomes ">=1.2.3 <2.0.0-0".
+func (r Range) String() string {
+	sets := make([]string, len(r.sets))
+	for i, set := range r.sets {
+		if len(set) == 0 {
+			sets[i] = "*"
+			continue
+		}
+		parts := make([]string, len(set))
+		for j, c := range set {
+			if c.op == "=" {
+				parts[j] = c.v.String()
+			} else {
+				parts[j] = c.op + c.v.String()
+			}
+		}
+		sets[i] = strings.Join(parts, " ")
+	}
+	return strings.Join(sets, " || ")
+}
+
+func setContains(set []comparator, v Version) bool {
+	for _, c := range set {
+		if !c.matches(v) {
+			return false
+		}
+	}
+	if !v.IsPrerelease() {
+		return true
+	}
+	for _, c := range set {
+		if c.v.IsPrerelease() && c.v.Major == v.Major && c.v.Minor == v.Minor && c.v.Patch == v.Patch {
+			return true
+		}
+	}
+	return false
+}
+
+func (c comparator) matches(v Version) bool {
+	cmp := Compare(v, c.v)
+	switch c.op {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return cmp == 0
+}
+
+func parseSet(s string) ([]comparator, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 3 && fields[1] == "-" {
+		return hyphenRange(fields[0], fields[2])
+	}
+
+	// allow a space between an operator and its version: ">= 1.2.3"
+	var tokens []string
+	for i := 0; i < len(fields); i++ {
+		tok := fields[i]
+		if tok == "-" {
+			return nil, errors.New(`a hyphen range needs exactly one version on each side of " - "`)
+		}
+		if strings.Trim(tok, "<>=~^") == "" && i+1 < len(fields) {
+			tok += fields[i+1]
+			i++
+		}
+		tokens = append(tokens, tok)
+	}
+
+	set := make([]comparator, 0, len(tokens))
+	for _, tok := range tokens {
+		cs, err := parseComparator(tok)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, cs...)
+	}
+	return set, nil
+}
+
+func parseComparator(tok string) ([]comparator, error) {
+	op := ""
+	for _, candidate := range []string{">=", "<=", "~>", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(tok, candidate) {
+			op, tok = candidate, tok[len(candidate):]
+			break
+		}
+	}
+	p, err := parsePartial(tok)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "", "=":
+		if p.n == 3 {
+			return []comparator{{"=", p.version()}}, nil
+		}
+		return p.span(), nil
+	case "~", "~>":
+		return p.tilde(), nil
+	case "^":
+		return p.caret(), nil
+	case ">=":
+		if p.n == 0 {
+			return nil, nil
+		}
+		return []comparator{{">=", p.version()}}, nil
+	case ">":
+		if p.n == 0 {
+			return []comparator{nothing()}, nil
+		}
+		if p.n == 3 {
+			return []comparator{{">", p.version()}}, nil
+		}
+		return []comparator{{">=", p.bump()}}, nil
+	case "<":
+		if p.n == 0 {
+			return []comparator{nothing()}, nil
+		}
+		if p.n == 3 {
+			return []comparator{{"<", p.version()}}, nil
+		}
+		return []comparator{{"<", floor(p.version())}}, nil
+	case "<=":
+		if p.n == 0 {
+			return nil, nil
+		}
+		if p.n == 3 {
+			return []comparator{{"<=", p.version()}}, nil
+		}
+		return []comparator{{"<", floor(p.bump())}}, nil
+	}
+	return nil, fmt.Errorf("unknown operator %q", op)
+}
+
+func hyphenRange(from, to string) ([]comparator, error) {
+	lo, err := parsePartial(from)
+	if err != nil {
+		return nil, err
+	}
+	hi, err := parsePartial(to)
+	if err != nil {
+		return nil, err
+	}
+	var set []comparator
+	if lo.n > 0 {
+		set = append(set, comparator{">=", lo.version()})
+	}
+	switch {
+	case hi.n == 3:
+		set = append(set, comparator{"<=", hi.version()})
+	case hi.n > 0:
+		set = append(set, comparator{"<", floor(hi.bump())})
+	}
+	return set, nil
+}
+
+func parsePartial(s string) (partial, error) {
+	var p partial
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "="), "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i] // build metadata never affects matching
+	}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		ids, err := identifiers(s[i+1:], true)
+		if err != nil {
+			return partial{}, fmt.Errorf("prerelease: %v", err)
+		}
+		p.pre, s = ids, s[:i]
+	}
+	if s == "" {
+		return partial{}, errors.New("missing version")
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return partial{}, fmt.Errorf("%q has too many parts", s)
+	}
+	wild := false
+	for i, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			wild = true
+			continue
+		}
+		if wild {
+			continue // "1.x.3" means "1.x"
+		}
+		n, err := numeric(part)
+		if err != nil {
+			return partial{}, err
+		}
+		p.nums[i] = n
+		p.n = i + 1
+	}
+	if p.pre != nil && p.n != 3 {
+		return partial{}, fmt.Errorf("prerelease on incomplete version %q", s)
+	}
+	return p, nil
+}
+
+func (p partial) version() Version {
+	return Version{Major: p.nums[0], Minor: p.nums[1], Patch: p.nums[2], Prerelease: p.pre}
+}
+
+// bump is the first version past the given numbers: "1.2" -> 1.3.0.
+func (p partial) bump() Version {
+	switch p.n {
+	case 1:
+		return Version{Major: p.nums[0] + 1}
+	case 2:
+		return Version{Major: p.nums[0], Minor: p.nums[1] + 1}
+	}
+	return Version{Major: p.nums[0], Minor: p.nums[1], Patch: p.nums[2] + 1}
+}
+
+// span is the x-range a bare partial stands for: "1.2" -> >=1.2.0 <1.3.0-0.
+func (p partial) span() []comparator {
+	if p.n == 0 {
+		return nil
+	}
+	return []comparator{{">=", p.version()}, {"<", floor(p.bump())}}
+}
+
+// tilde allows patch-level changes: "~1.2.3" -> >=1.2.3 <1.3.0-0.
+func (p partial) tilde() []comparator {
+	if p.n < 3 {
+		return p.span()
+	}
+	upper := Version{Major: p.nums[0], Minor: p.nums[1] + 1}
+	return []comparator{{">=", p.version()}, {"<", floor(upper)}}
+}
+
+// caret allows changes that do not modify the left-most non-zero number:
+// "^1.2.3" -> <2.0.0-0, "^0.2.3" -> <0.3.0-0, "^0.0.3" -> <0.0.4-0.
+func (p partial) caret() []comparator {
+	if p.n == 0 {
+		return nil
+	}
+	var upper Version
+	switch {
+	case p.nums[0] > 0 || p.n == 1:
+		upper = Version{Major: p.nums[0] + 1}
+	case p.nums[1] > 0 || p.n == 2:
+		upper = Version{Minor: p.nums[1] + 1}
+	default:
+		upper = Version{Patch: p.nums[2] + 1}
+	}
+	return []comparator{{">=", p.version()}, {"<", floor(upper)}}
+}
+
+// floor is the lowest version with v's numbers, so "<floor(2.0.0)" also
+// excludes 2.0.0's prereleases.
+func floor(v Version) Version {
+	v.Prerelease = []string{"0"}
+	return v
+}
+
+// nothing is a comparator no version satisfies.
+func nothing() comparator {
+	return comparator{"<", floor(Version{})}
+}
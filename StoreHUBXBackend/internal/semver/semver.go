@@ -0,0 +1,201 @@
+// Package semver parses and orders Semantic Versioning 2.0.0 version strings.
+package semver
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalid = errors.New("invalid semantic version")
+
+type Version struct {
+	Major, Minor, Patch uint64
+	Prerelease          []string // dot-separated identifiers after "-"
+	Build               []string // dot-separated identifiers after "+"; ignored for ordering
+}
+
+// Parse parses a strict SemVer 2.0.0 string such as "1.4.0", "2.0.0-rc.1" or
+// "1.0.0+20240101". A leading "v" is not accepted.
+func Parse(s string) (Version, error) {
+	var v Version
+	rest := s
+	if i := strings.IndexByte(rest, '+'); i >= 0 {
+		ids, err := identifiers(rest[i+1:], false)
+		if err != nil {
+			return Version{}, fmt.Errorf("%w %q: build metadata: %v", ErrInvalid, s, err)
+		}
+		v.Build, rest = ids, rest[:i]
+	}
+	if i := strings.IndexByte(rest, '-'); i >= 0 {
+		ids, err := identifiers(rest[i+1:], true)
+		if err != nil {
+			return Version{}, fmt.Errorf("%w %q: prerelease: %v", ErrInvalid, s, err)
+		}
+		v.Prerelease, rest = ids, rest[:i]
+	}
+
+	parts := strings.Split(rest, ".")
+	if len(parts) != 3 {
+		return Version{}, fmt.Errorf("%w %q: want MAJOR.MINOR.PATCH", ErrInvalid, s)
+	}
+	nums := [3]*uint64{&v.Major, &v.Minor, &v.Patch}
+	for i, p := range parts {
+		n, err := numeric(p)
+		if err != nil {
+			return Version{}, fmt.Errorf("%w %q: %v", ErrInvalid, s, err)
+		}
+		*nums[i] = n
+	}
+	return v, nil
+}
+
+// MustParse is Parse for known-good literals.
+func MustParse(s string) Version {
+	v, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// Valid reports whether s is a strict semantic version.
+func Valid(s string) bool {
+	_, err := Parse(s)
+	return err == nil
+}
+
+func numeric(p string) (uint64, error) {
+	if p == "" {
+		return 0, errors.New("empty number")
+	}
+	if len(p) > 1 && p[0] == '0' {
+		return 0, fmt.Errorf("leading zero in %q", p)
+	}
+	for _, r := range p {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("%q is not a number", p)
+		}
+	}
+	return strconv.ParseUint(p, 10, 64)
+}
+
+func identifiers(s string, prerelease bool) ([]string, error) {
+	ids := strings.Split(s, ".")
+	for _, id := range ids {
+		if id == "" {
+			return nil, errors.New("empty identifier")
+		}
+		for _, r := range id {
+			if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '-') {
+				return nil, fmt.Errorf("invalid character %q in %q", r, id)
+			}
+		}
+		if prerelease && isNumeric(id) && len(id) > 1 && id[0] == '0' {
+			return nil, fmt.Errorf("leading zero in %q", id)
+		}
+	}
+	return ids, nil
+}
+
+func isNumeric(id string) bool {
+	for _, r := range id {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return id != ""
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	if len(v.Build) > 0 {
+		s += "+" + strings.Join(v.Build, ".")
+	}
+	return s
+}
+
+func (v Version) IsPrerelease() bool {
+	return len(v.Prerelease) > 0
+}
+
+// NextPatch is the version a patch release after v gets: a prerelease
+// becomes its release ("1.3.0-beta.2" -> "1.3.0"), otherwise the patch is
+// bumped. Build metadata is dropped.
+func (v Version) NextPatch() Version {
+	if v.IsPrerelease() {
+		return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+	}
+	return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+}
+
+// Compare orders a and b by SemVer precedence: -1, 0 or +1. Build metadata
+// does not take part, so "1.0.0+a" and "1.0.0+b" compare equal.
+func Compare(a, b Version) int {
+	if c := cmpUint(a.Major, b.Major); c != 0 {
+		return c
+	}
+	if c := cmpUint(a.Minor, b.Minor); c != 0 {
+		return c
+	}
+	if c := cmpUint(a.Patch, b.Patch); c != 0 {
+		return c
+	}
+	// a version without prerelease has higher precedence
+	switch {
+	case len(a.Prerelease) == 0 && len(b.Prerelease) == 0:
+		return 0
+	case len(a.Prerelease) == 0:
+		return 1
+	case len(b.Prerelease) == 0:
+		return -1
+	}
+	for i := 0; i < len(a.Prerelease) && i < len(b.Prerelease); i++ {
+		if c := compareIdentifier(a.Prerelease[i], b.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return cmpUint(uint64(len(a.Prerelease)), uint64(len(b.Prerelease)))
+}
+
+// LessThan reports whether v has lower precedence than o.
+func (v Version) LessThan(o Version) bool {
+	return Compare(v, o) < 0
+}
+
+// numeric identifiers compare numerically and rank below alphanumeric ones
+func compareIdentifier(a, b string) int {
+	an, bn := isNumeric(a), isNumeric(b)
+	switch {
+	case an && bn:
+		if len(a) != len(b) {
+			return cmpUint(uint64(len(a)), uint64(len(b)))
+		}
+		return strings.Compare(a, b)
+	case an:
+		return -1
+	case bn:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func cmpUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Sort orders versions from lowest to highest precedence.
+func Sort(vs []Version) {
+	sort.SliceStable(vs, func(i, j int) bool { return Compare(vs[i], vs[j]) < 0 })
+}
@@ -0,0 +1,143 @@
+package semver
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	valid := []string{"0.0.0", "1.4.0", "2.0.0-rc.1", "1.0.0+20240101", "1.0.0-alpha.beta+exp.sha.5"}
+	for _, s := range valid {
+		v, err := Parse(s)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", s, err)
+			continue
+		}
+		if v.String() != s {
+			t.Errorf("Parse(%q).String() = %q", s, v.String())
+		}
+	}
+	invalid := []string{"", "1", "1.2", "v1.2.3", "01.2.3", "1.2.3.4", "1.2.3-", "1.2.3-01", "1.2.3+", "1.2.x"}
+	for _, s := range invalid {
+		if Valid(s) {
+			t.Errorf("Valid(%q) = true", s)
+		}
+	}
+}
+
+func TestSort(t *testing.T) {
+	// the SemVer 2.0.0 spec's precedence example, shuffled
+	in := "1.0.0 1.0.0-rc.1 1.0.0-alpha.beta 1.0.0-beta.11 1.0.0-alpha 1.0.0-beta.2 1.0.0-beta 1.0.0-alpha.1"
+	want := "1.0.0-alpha 1.0.0-alpha.1 1.0.0-alpha.beta 1.0.0-beta 1.0.0-beta.2 1.0.0-beta.11 1.0.0-rc.1 1.0.0"
+
+	var vs []Version
+	for _, s := range strings.Fields(in) {
+		vs = append(vs, MustParse(s))
+	}
+	Sort(vs)
+	got := make([]string, len(vs))
+	for i, v := range vs {
+		got[i] = v.String()
+	}
+	if strings.Join(got, " ") != want {
+		t.Errorf("Sort:\n got %s\nwant %s", strings.Join(got, " "), want)
+	}
+}
+
+func TestRange(t *testing.T) {
+	tests := []struct {
+		rng    string
+		norm   string
+		match  string
+		reject string
+	}{
+		// caret
+		{"^1.2.3", ">=1.2.3 <2.0.0-0", "1.2.3 1.9.9", "1.2.2 2.0.0 2.0.0-0 1.3.0-beta.1"},
+		{"^0.2.3", ">=0.2.3 <0.3.0-0", "0.2.3 0.2.9", "0.3.0 0.2.2"},
+		{"^0.0.3", ">=0.0.3 <0.0.4-0", "0.0.3", "0.0.4 0.0.2"},
+		{"^1.2", ">=1.2.0 <2.0.0-0", "1.2.0 1.8.0", "1.1.9 2.0.0"},
+		// tilde
+		{"~1.2.3", ">=1.2.3 <1.3.0-0", "1.2.3 1.2.10", "1.3.0 1.2.2"},
+		{"~1.2", ">=1.2.0 <1.3.0-0", "1.2.0 1.2.9", "1.3.0"},
+		{"~>1.2.3", ">=1.2.3 <1.3.0-0", "1.2.4", "1.3.0"},
+		// x-ranges
+		{"*", "*", "0.0.1 9.9.9", "1.0.0-rc.1"},
+		{"", "*", "1.0.0", ""},
+		{"1.x", ">=1.0.0 <2.0.0-0", "1.0.0 1.99.0", "2.0.0 0.9.9"},
+		{"1.2.*", ">=1.2.0 <1.3.0-0", "1.2.7", "1.3.0"},
+		{">1.2", ">=1.3.0", "1.3.0", "1.2.9"},
+		{"<=1.2", "<1.3.0-0", "1.2.9", "1.3.0"},
+		// hyphen
+		{"1.2.3 - 2.3.4", ">=1.2.3 <=2.3.4", "1.2.3 2.3.4", "1.2.2 2.3.5"},
+		{"1.2 - 2.3", ">=1.2.0 <2.4.0-0", "2.3.9", "2.4.0 1.1.0"},
+		// comparators and unions
+		{">= 1.2.3 < 2", ">=1.2.3 <2.0.0-0", "1.5.0", "2.0.0"},
+		{"1.2.3", "1.2.3", "1.2.3 1.2.3+build.7", "1.2.4"},
+		{"^1.0.0 || ^3.0.0", ">=1.0.0 <2.0.0-0 || >=3.0.0 <4.0.0-0", "1.1.0 3.2.0", "2.0.0 4.0.0"},
+		// prereleases only match a comparator on the same MAJOR.MINOR.PATCH
+		{">=1.3.0-beta.0 <2", ">=1.3.0-beta.0 <2.0.0-0", "1.3.0-beta.0 1.3.0-beta.2 1.3.0 1.4.0", "1.4.0-beta.1 1.3.0-alpha"},
+		{"^2.0.0-rc.1", ">=2.0.0-rc.1 <3.0.0-0", "2.0.0-rc.1 2.0.0-rc.2 2.0.0", "2.0.0-beta 2.1.0-rc.1"},
+		{"~1.2.3-beta.2", ">=1.2.3-beta.2 <1.3.0-0", "1.2.3-beta.4 1.2.3 1.2.5", "1.2.3-beta.1 1.2.4-beta.1"},
+	}
+	for _, tt := range tests {
+		r, err := ParseRange(tt.rng)
+		if err != nil {
+			t.Errorf("ParseRange(%q): %v", tt.rng, err)
+			continue
+		}
+		if got := r.String(); got != tt.norm {
+			t.Errorf("ParseRange(%q).String() = %q, want %q", tt.rng, got, tt.norm)
+		}
+		for _, s := range strings.Fields(tt.match) {
+			if !r.Contains(MustParse(s)) {
+				t.Errorf("%q should contain %s", tt.rng, s)
+			}
+		}
+		for _, s := range strings.Fields(tt.reject) {
+			if r.Contains(MustParse(s)) {
+				t.Errorf("%q should not contain %s", tt.rng, s)
+			}
+		}
+	}
+}
+
+func TestParseRangeInvalid(t *testing.T) {
+	for _, s := range []string{"1.2.3 -", "- 1.2.3", "1.2.3 - 2 - 3", "^a.b", "1.2-rc.1", "1.2.3.4", ">=x.y.z.w"} {
+		if _, err := ParseRange(s); err == nil {
+			t.Errorf("ParseRange(%q) succeeded", s)
+		}
+	}
+}
+
+func TestMaxSatisfying(t *testing.T) {
+	var vs []Version
+	for _, s := range strings.Fields("1.0.0 1.2.0 1.3.0-beta.1 2.0.0 2.1.0-rc.1") {
+		vs = append(vs, MustParse(s))
+	}
+	tests := map[string]string{
+		"^1.0.0":            "1.2.0",
+		"~1.0":              "1.0.0",
+		">=1.3.0-beta.0 <2": "1.3.0-beta.1",
+		"*":                 "2.0.0",
+		"^3":                "",
+	}
+	for rng, want := range tests {
+		v, ok := mustRange(t, rng).MaxSatisfying(vs)
+		got := ""
+		if ok {
+			got = v.String()
+		}
+		if got != want {
+			t.Errorf("MaxSatisfying(%q) = %q, want %q", rng, got, want)
+		}
+	}
+}
+
+func mustRange(t *testing.T, s string) Range {
+	t.Helper()
+	r, err := ParseRange(s)
+	if err != nil {
+		t.Fatalf("ParseRange(%q): %v", s, err)
+	}
+	return r
+}
@@ -0,0 +1,371 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FSUploader stores objects as files under a root directory, using the object
+// key as the relative path. The API process serves the root on /static (see
+// routes.RegisterStatic), so published previews work without S3 or MinIO.
+//
+// Content types follow the file extension. An explicit type that differs from
+// it, and user metadata, are kept in a JSON sidecar under <root>/.meta/.
+type FSUploader struct {
+	root       string
+	publicBase string
+}
+
+// NewFSUploader stores files under root and builds public URLs from
+// publicBase, which must point at the route serving root.
+func NewFSUploader(root, publicBase string) (*FSUploader, error) {
+	if root == "" {
+		return nil, errors.New("storage root is empty")
+	}
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("storage root: %w", err)
+	}
+	if err := os.MkdirAll(abs, 0o755); err != nil {
+		return nil, fmt.Errorf("creating storage root %q failed: %w", abs, err)
+	}
+	if _, err := url.Parse(publicBase); err != nil {
+		return nil, fmt.Errorf("invalid public base URL: %w", err)
+	}
+	return &FSUploader{root: abs, publicBase: strings.TrimRight(publicBase, "/")}, nil
+}
+
+// metaDir holds the sidecars; it is never listed or served.
+const metaDir = ".meta"
+
+// fsMeta is what a sidecar records.
+type fsMeta struct {
+	ContentType string            `json:"contentType,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// Root is the directory objects are stored in.
+func (u *FSUploader) Root() string {
+	return u.root
+}
+
+// PublishComponentFromDist lays out dist exactly like S3Uploader does:
+// assets/* recursively, other top-level files as-is and the rewritten
+// index.html, all under components/<component>/<version>/.
+func (u *FSUploader) PublishComponentFromDist(ctx context.Context, component, version, distDir string) (string, error) {
+	return publishDist(ctx, u, component, version, distDir)
+}
+
+// Put writes data to key. The content type only matters for HTML, which gets
+// a doctype like on S3; files are served with the type of their extension.
+func (u *FSUploader) Put(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	if contentType == "" {
+		contentType = detectContentTypeFromExt(key)
+	}
+	if contentType == "text/html" {
+		data = ensureHTMLDoctype(data)
+	}
+	if err := u.write(key, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	}); err != nil {
+		return "", fmt.Errorf("put failed: %w", err)
+	}
+	if err := u.writeMeta(key, fsMeta{ContentType: contentType}); err != nil {
+		return "", fmt.Errorf("put failed: %w", err)
+	}
+	return u.PublicURL(key), nil
+}
+
+// PutFile copies localPath to key.
+func (u *FSUploader) PutFile(ctx context.Context, key string, localPath string, contentType string) (string, error) {
+	if contentType == "" {
+		contentType = detectContentTypeFromExt(localPath)
+	}
+	if contentType == "text/html" {
+		content, err := os.ReadFile(localPath)
+		if err != nil {
+			return "", fmt.Errorf("read html file: %w", err)
+		}
+		return u.Put(ctx, key, content, contentType)
+	}
+
+	src, err := os.Open(localPath)
+	if err != nil {
+		return "", fmt.Errorf("open %s: %w", localPath, err)
+	}
+	defer src.Close()
+	if err := u.write(key, func(w io.Writer) error {
+		_, err := io.Copy(w, src)
+		return err
+	}); err != nil {
+		return "", fmt.Errorf("put file: %w", err)
+	}
+	if err := u.writeMeta(key, fsMeta{ContentType: contentType}); err != nil {
+		return "", fmt.Errorf("put file: %w", err)
+	}
+	return u.PublicURL(key), nil
+}
+
+func (u *FSUploader) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(u.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return data, err
+}
+
+func (u *FSUploader) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	fi, err := os.Stat(u.path(key))
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && fi.IsDir()) {
+		return ObjectInfo{}, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	return u.info(u.key(u.path(key)), fi), nil
+}
+
+func (u *FSUploader) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	err := u.walk(ctx, prefix, func(key, p string, d fs.DirEntry) error {
+		if d.IsDir() {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, u.info(key, fi))
+		return nil
+	})
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, err
+}
+
+func (u *FSUploader) Delete(ctx context.Context, key string) error {
+	if err := os.Remove(u.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return u.writeMeta(key, fsMeta{})
+}
+
+// DeletePrefix removes every file whose key starts with prefix, then any
+// directories left empty, and returns how many files were deleted.
+func (u *FSUploader) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	deleted := 0
+	var dirs []string
+	err := u.walk(ctx, prefix, func(key, p string, d fs.DirEntry) error {
+		if d.IsDir() {
+			dirs = append(dirs, p)
+			return nil
+		}
+		if err := u.Delete(ctx, key); err != nil {
+			return fmt.Errorf("remove %s: %w", key, err)
+		}
+		deleted++
+		return nil
+	})
+
+	// deepest first, so emptied parents go too; os.Remove fails on non-empty ones
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if dirs[i] != u.root {
+			_ = os.Remove(dirs[i])
+		}
+	}
+	return deleted, err
+}
+
+func (u *FSUploader) Copy(ctx context.Context, src, dst string) error {
+	in, err := os.Open(u.path(src))
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrNotFound, src)
+	}
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := u.write(dst, func(w io.Writer) error {
+		_, err := io.Copy(w, in)
+		return err
+	}); err != nil {
+		return err
+	}
+	return u.writeMeta(dst, u.readMeta(src))
+}
+
+func (u *FSUploader) SetMetadata(ctx context.Context, key, contentType string, metadata map[string]string) error {
+	if _, err := u.Stat(ctx, key); err != nil {
+		return err
+	}
+	m := u.readMeta(key)
+	if contentType != "" {
+		m.ContentType = contentType
+	}
+	if metadata != nil {
+		m.Metadata = metadata
+	}
+	return u.writeMeta(key, m)
+}
+
+// PresignGet returns the public URL: the /static route does not check access.
+func (u *FSUploader) PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	return u.PublicURL(key), nil
+}
+
+// ContentType is the type key is served with: the one recorded for it, else
+// the one its extension maps to.
+func (u *FSUploader) ContentType(key string) string {
+	if ct := u.readMeta(key).ContentType; ct != "" {
+		return ct
+	}
+	if ct := detectContentTypeFromExt(key); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
+
+// PublicURL is the URL the /static route serves key on.
+func (u *FSUploader) PublicURL(key string) string {
+	return u.publicBase + "/" + strings.TrimLeft(path.Clean("/"+key), "/")
+}
+
+// write stores key through a temp file in the same directory, so readers
+// never see a partially written object.
+func (u *FSUploader) write(key string, fill func(io.Writer) error) error {
+	dst := u.path(key)
+	if dst == u.root {
+		return fmt.Errorf("invalid key %q", key)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".put-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := fill(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+// walk calls fn for every object below prefix and for the directories whose
+// keys fall under prefix, skipping sidecars and files still being written.
+// Directories above prefix are walked through without calling fn.
+func (u *FSUploader) walk(ctx context.Context, prefix string, fn func(key, p string, d fs.DirEntry) error) error {
+	// start at the deepest directory that can contain matching keys
+	dirKey := prefix
+	if !strings.HasSuffix(dirKey, "/") {
+		dirKey = path.Dir(dirKey)
+	}
+	return filepath.WalkDir(u.path(dirKey), func(p string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			if errors.Is(walkErr, fs.ErrNotExist) {
+				return nil
+			}
+			return walkErr
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		key := u.key(p)
+		if d.IsDir() {
+			if key == metaDir {
+				return filepath.SkipDir
+			}
+			dirKey := key + "/"
+			if p == u.root {
+				dirKey = ""
+			}
+			switch {
+			case strings.HasPrefix(dirKey, prefix):
+				return fn(key, p, d)
+			case strings.HasPrefix(prefix, dirKey):
+				return nil // an ancestor of the prefix
+			}
+			return filepath.SkipDir
+		}
+		if strings.HasPrefix(d.Name(), ".put-") || !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		return fn(key, p, d)
+	})
+}
+
+func (u *FSUploader) info(key string, fi fs.FileInfo) ObjectInfo {
+	m := u.readMeta(key)
+	return ObjectInfo{
+		Key:          key,
+		Size:         fi.Size(),
+		ContentType:  u.ContentType(key),
+		LastModified: fi.ModTime(),
+		Metadata:     m.Metadata,
+	}
+}
+
+func (u *FSUploader) metaPath(key string) string {
+	return filepath.Join(u.root, metaDir, filepath.FromSlash(path.Clean("/"+key))+".json")
+}
+
+func (u *FSUploader) readMeta(key string) fsMeta {
+	var m fsMeta
+	if b, err := os.ReadFile(u.metaPath(key)); err == nil {
+		_ = json.Unmarshal(b, &m)
+	}
+	return m
+}
+
+// writeMeta stores m as key's sidecar. A content type its extension already
+// implies is not recorded, and an empty sidecar is removed.
+func (u *FSUploader) writeMeta(key string, m fsMeta) error {
+	if strings.EqualFold(m.ContentType, detectContentTypeFromExt(key)) {
+		m.ContentType = ""
+	}
+	p := u.metaPath(key)
+	if m.ContentType == "" && len(m.Metadata) == 0 {
+		if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(p, b, 0o644)
+}
+
+// path maps a key to its file. Cleaning it as an absolute path drops any
+// ".." so keys cannot escape the root.
+func (u *FSUploader) path(key string) string {
+	return filepath.Join(u.root, filepath.FromSlash(path.Clean("/"+key)))
+}
+
+// key maps a file below the root back to its key.
+func (u *FSUploader) key(p string) string {
+	rel, _ := filepath.Rel(u.root, p)
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,211 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Object is one stored object as MemoryUploader recorded it.
+type Object struct {
+	Key         string
+	Data        []byte
+	ContentType string
+	Metadata    map[string]string
+	ModTime     time.Time
+}
+
+func (o Object) info() ObjectInfo {
+	return ObjectInfo{Key: o.Key, Size: int64(len(o.Data)), ContentType: o.ContentType, LastModified: o.ModTime, Metadata: o.Metadata}
+}
+
+// MemoryUploader keeps objects in memory. It publishes with the same key
+// layout and content-type rules as S3Uploader, which makes it a stand-in for
+// a bucket when running the worker pipeline in tests or locally.
+type MemoryUploader struct {
+	publicBase string
+
+	mu      sync.Mutex
+	objects map[string]Object
+}
+
+// NewMemoryUploader returns an empty store whose public URLs start with
+// "memory://".
+func NewMemoryUploader() *MemoryUploader {
+	return &MemoryUploader{publicBase: "memory://", objects: map[string]Object{}}
+}
+
+func (u *MemoryUploader) PublishComponentFromDist(ctx context.Context, component, version, distDir string) (string, error) {
+	return publishDist(ctx, u, component, version, distDir)
+}
+
+// Put stores a copy of data. An empty content type is detected from the key,
+// then the data, like S3Uploader.Put.
+func (u *MemoryUploader) Put(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if contentType == "" {
+		contentType = detectContentTypeFromExt(key)
+	}
+	if contentType == "" && len(data) > 0 {
+		contentType = http.DetectContentType(data)
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	if contentType == "text/html" {
+		data = ensureHTMLDoctype(data)
+	}
+
+	u.mu.Lock()
+	u.objects[key] = Object{Key: key, Data: append([]byte(nil), data...), ContentType: contentType, ModTime: time.Now()}
+	u.mu.Unlock()
+	return u.PublicURL(key), nil
+}
+
+// PutFile reads localPath and stores it like Put.
+func (u *MemoryUploader) PutFile(ctx context.Context, key string, localPath string, contentType string) (string, error) {
+	data, err := os.ReadFile(localPath)
+	if err != nil {
+		return "", fmt.Errorf("read %s: %w", localPath, err)
+	}
+	if contentType == "" {
+		contentType = detectContentTypeFromExt(localPath)
+	}
+	return u.Put(ctx, key, data, contentType)
+}
+
+func (u *MemoryUploader) Get(ctx context.Context, key string) ([]byte, error) {
+	obj, ok := u.Object(key)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	// a copy, so callers cannot change the stored object
+	return append([]byte(nil), obj.Data...), nil
+}
+
+func (u *MemoryUploader) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	obj, ok := u.Object(key)
+	if !ok {
+		return ObjectInfo{}, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return obj.info(), nil
+}
+
+func (u *MemoryUploader) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	objs := u.Objects(prefix)
+	out := make([]ObjectInfo, len(objs))
+	for i, obj := range objs {
+		out[i] = obj.info()
+	}
+	return out, nil
+}
+
+func (u *MemoryUploader) Delete(ctx context.Context, key string) error {
+	u.mu.Lock()
+	delete(u.objects, key)
+	u.mu.Unlock()
+	return nil
+}
+
+func (u *MemoryUploader) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	deleted := 0
+	for key := range u.objects {
+		if strings.HasPrefix(key, prefix) {
+			delete(u.objects, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (u *MemoryUploader) Copy(ctx context.Context, src, dst string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	obj, ok := u.objects[src]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, src)
+	}
+	obj.Key, obj.ModTime = dst, time.Now()
+	obj.Metadata = copyMap(obj.Metadata)
+	u.objects[dst] = obj
+	return nil
+}
+
+func (u *MemoryUploader) SetMetadata(ctx context.Context, key, contentType string, metadata map[string]string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	obj, ok := u.objects[key]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	if contentType != "" {
+		obj.ContentType = contentType
+	}
+	if metadata != nil {
+		obj.Metadata = copyMap(metadata)
+	}
+	u.objects[key] = obj
+	return nil
+}
+
+func (u *MemoryUploader) PublicURL(key string) string {
+	return u.publicBase + strings.TrimLeft(key, "/")
+}
+
+// PresignGet returns the public URL; memory objects have no access control.
+func (u *MemoryUploader) PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	return u.PublicURL(key), nil
+}
+
+// Object returns the object stored under key.
+func (u *MemoryUploader) Object(key string) (Object, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	obj, ok := u.objects[key]
+	return obj, ok
+}
+
+// Objects returns every stored object whose key starts with prefix, sorted
+// by key.
+func (u *MemoryUploader) Objects(prefix string) []Object {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	out := make([]Object, 0, len(u.objects))
+	for key, obj := range u.objects {
+		if strings.HasPrefix(key, prefix) {
+			out = append(out, obj)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+// Keys returns the sorted keys starting with prefix.
+func (u *MemoryUploader) Keys(prefix string) []string {
+	objs := u.Objects(prefix)
+	keys := make([]string, len(objs))
+	for i, obj := range objs {
+		keys[i] = obj.Key
+	}
+	return keys
+}
+
+func copyMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
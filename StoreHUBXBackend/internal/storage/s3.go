@@ -13,6 +13,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
@@ -77,7 +78,7 @@ func NewS3Uploader() (*S3Uploader, error) {
 	}
 
 	// best-effort policy set
-	_ = client.SetBucketPolicy(ctx, bucket, fmt.Sprintf(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":["s3:GetObject","s3:ListBucket"],"Resource":["arn:aws:s3:::%s/*","arn:aws:s3:::%s"]}]}`, bucket, bucket))
+	_ = u.SetPublicReadPolicy(ctx)
 
 	return u, nil
 }
@@ -381,7 +382,7 @@ func detectContentTypeFromExt(pathOrName string) string {
 			return "font/otf"
 		case ".map":
 			return "application/json"
-		case ".txt":
+		case ".txt", ".log":
 			return "text/plain"
 		case ".xml":
 			return "application/xml"
@@ -462,40 +463,180 @@ func (u *S3Uploader) PutFile(ctx context.Context, key string, localPath string,
 	return u.publicURL(key), nil
 }
 
-// ListObjects lists all objects with a given prefix in the bucket
-func (u *S3Uploader) ListObjects(ctx context.Context, prefix string) ([]string, error) {
-	var objects []string
+func (u *S3Uploader) Get(ctx context.Context, key string) ([]byte, error) {
+	obj, err := u.client.GetObject(ctx, u.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, s3Err(err)
+	}
+	defer obj.Close()
+	data, err := io.ReadAll(obj)
+	if err != nil {
+		return nil, s3Err(err)
+	}
+	return data, nil
+}
+
+func (u *S3Uploader) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	info, err := u.client.StatObject(ctx, u.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return ObjectInfo{}, s3Err(err)
+	}
+	return objectInfo(info), nil
+}
+
+func (u *S3Uploader) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
 	for obj := range u.client.ListObjects(ctx, u.bucket, minio.ListObjectsOptions{
-		Prefix:    prefix,
-		Recursive: true,
+		Prefix:       prefix,
+		Recursive:    true,
+		WithMetadata: true, // MinIO returns content types; S3 ignores it
 	}) {
 		if obj.Err != nil {
 			return nil, obj.Err
 		}
-		objects = append(objects, obj.Key)
+		objects = append(objects, objectInfo(obj))
 	}
 	return objects, nil
 }
 
-// UpdateObjectContentType updates the object's system Content-Type using server-side copy (ReplaceMetadata).
-// Falls back to streaming to a temp file then FPutObject if CopyObject fails.
+func (u *S3Uploader) Delete(ctx context.Context, key string) error {
+	return s3Err(u.client.RemoveObject(ctx, u.bucket, key, minio.RemoveObjectOptions{}))
+}
+
+func (u *S3Uploader) Copy(ctx context.Context, src, dst string) error {
+	_, err := u.client.CopyObject(ctx,
+		minio.CopyDestOptions{Bucket: u.bucket, Object: dst},
+		minio.CopySrcOptions{Bucket: u.bucket, Object: src},
+	)
+	return s3Err(err)
+}
+
+// PresignGet signs a GET request for key with the uploader's credentials.
+func (u *S3Uploader) PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	signed, err := u.client.PresignedGetObject(ctx, u.bucket, key, expiry, nil)
+	if err != nil {
+		return "", err
+	}
+	return signed.String(), nil
+}
+
+// Bucket is the bucket objects are stored in.
+func (u *S3Uploader) Bucket() string {
+	return u.bucket
+}
+
+// SetPublicReadPolicy lets anyone read and list the bucket, which previews
+// need when they are served straight from it.
+func (u *S3Uploader) SetPublicReadPolicy(ctx context.Context) error {
+	return u.client.SetBucketPolicy(ctx, u.bucket, publicReadPolicy(u.bucket))
+}
+
+func publicReadPolicy(bucket string) string {
+	return fmt.Sprintf(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":["s3:GetObject","s3:ListBucket"],"Resource":["arn:aws:s3:::%s/*","arn:aws:s3:::%s"]}]}`, bucket, bucket)
+}
+
+// objectInfo converts minio's object info; user metadata keys lose the
+// X-Amz-Meta- prefix and are lowercased.
+func objectInfo(info minio.ObjectInfo) ObjectInfo {
+	meta := map[string]string{}
+	for k, v := range info.UserMetadata {
+		meta[strings.ToLower(strings.TrimPrefix(k, "X-Amz-Meta-"))] = v
+	}
+	return ObjectInfo{
+		Key:          info.Key,
+		Size:         info.Size,
+		ContentType:  info.ContentType,
+		LastModified: info.LastModified,
+		Metadata:     meta,
+	}
+}
+
+// s3Err maps missing objects to ErrNotFound.
+func s3Err(err error) error {
+	if err == nil {
+		return nil
+	}
+	if code := minio.ToErrorResponse(err).Code; code == "NoSuchKey" || code == "NotFound" {
+		return fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+	return err
+}
+
+// DeletePrefix removes every object whose key starts with prefix and returns how many were deleted.
+func (u *S3Uploader) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	// canceled on return so the lister never blocks on a removal that gave up
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	objectsCh := make(chan minio.ObjectInfo)
+	listErr := make(chan error, 1)
+	go func() {
+		defer close(objectsCh)
+		for obj := range u.client.ListObjects(ctx, u.bucket, minio.ListObjectsOptions{
+			Prefix:    prefix,
+			Recursive: true,
+		}) {
+			if obj.Err != nil {
+				listErr <- obj.Err
+				return
+			}
+			select {
+			case objectsCh <- obj:
+			case <-ctx.Done():
+				listErr <- ctx.Err()
+				return
+			}
+		}
+		listErr <- nil
+	}()
+
+	deleted := 0
+	var firstErr error
+	for rErr := range u.client.RemoveObjectsWithResult(ctx, u.bucket, objectsCh, minio.RemoveObjectsOptions{}) {
+		if rErr.Err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("remove %s: %w", rErr.ObjectName, rErr.Err)
+			}
+			continue
+		}
+		deleted++
+	}
+	cancel() // removal may have stopped reading before the listing ended
+	if err := <-listErr; err != nil && firstErr == nil {
+		return deleted, fmt.Errorf("list objects: %w", err)
+	}
+	return deleted, firstErr
+}
+
+// UpdateObjectContentType updates the object's system Content-Type, keeping its user metadata.
 func (u *S3Uploader) UpdateObjectContentType(ctx context.Context, key, contentType string) error {
+	return u.SetMetadata(ctx, key, contentType, nil)
+}
+
+// SetMetadata rewrites the object's metadata using server-side copy (ReplaceMetadata).
+// Falls back to streaming to a temp file then FPutObject if CopyObject fails.
+func (u *S3Uploader) SetMetadata(ctx context.Context, key, contentType string, metadata map[string]string) error {
 	objInfo, err := u.client.StatObject(ctx, u.bucket, key, minio.StatObjectOptions{})
 	if err != nil {
-		return fmt.Errorf("stat object failed: %w", err)
+		return fmt.Errorf("stat object failed: %w", s3Err(err))
+	}
+	if contentType == "" {
+		contentType = objInfo.ContentType
 	}
-	if strings.EqualFold(objInfo.ContentType, contentType) {
+	if metadata == nil && strings.EqualFold(objInfo.ContentType, contentType) {
 		return nil
 	}
 
 	// prepare user metadata - do not include system headers
 	userMeta := map[string]string{}
-	for k, v := range objInfo.UserMetadata {
-		lk := strings.ToLower(k)
-		if lk == "content-type" || lk == "content-length" {
-			continue
+	if metadata != nil {
+		for k, v := range metadata {
+			userMeta[k] = v
+		}
+	} else {
+		for k, v := range objectInfo(objInfo).Metadata {
+			userMeta[k] = v
 		}
-		userMeta[k] = v
 	}
 
 	dst := minio.CopyDestOptions{
@@ -543,64 +684,9 @@ func (u *S3Uploader) UpdateObjectContentType(ctx context.Context, key, contentTy
 	}
 }
 
-// FixMimeTypesForComponent runs after component upload to ensure proper content types for assets.
-// Can be called separately or automatically integrated with the build process.
-func (u *S3Uploader) FixMimeTypesForComponent(ctx context.Context, component, version string) error {
-	prefix := fmt.Sprintf("components/%s/%s/", component, version)
-
-	// Get all objects with prefix
-	objects, err := u.ListObjects(ctx, prefix)
-	if err != nil {
-		return fmt.Errorf("list objects: %w", err)
-	}
-
-	for _, key := range objects {
-		ext := strings.ToLower(filepath.Ext(key))
-		var ct string
-
-		switch ext {
-		case ".js", ".mjs":
-			ct = "application/javascript"
-		case ".css":
-			ct = "text/css"
-		case ".html":
-			ct = "text/html"
-		case ".svg":
-			ct = "image/svg+xml"
-		case ".json":
-			ct = "application/json"
-		case ".png":
-			ct = "image/png"
-		case ".jpg", ".jpeg":
-			ct = "image/jpeg"
-		case ".gif":
-			ct = "image/gif"
-		case ".woff":
-			ct = "font/woff"
-		case ".woff2":
-			ct = "font/woff2"
-		case ".ttf":
-			ct = "font/ttf"
-		case ".map":
-			ct = "application/json"
-		}
-
-		// Special handling for hashed filenames like index-ABC123.js
-		if ext == "" && strings.Contains(key, "index-") {
-			if strings.Contains(strings.ToLower(key), ".js") {
-				ct = "application/javascript"
-			} else if strings.Contains(strings.ToLower(key), ".css") {
-				ct = "text/css"
-			}
-		}
-
-		if ct != "" {
-			// Ignore errors, best-effort fix
-			_ = u.UpdateObjectContentType(ctx, key, ct)
-		}
-	}
-
-	return nil
+// PublicURL is the public URL of key.
+func (u *S3Uploader) PublicURL(key string) string {
+	return u.publicURL(key)
 }
 
 // publicURL builds a clean public URL and avoids doubling the bucket in the path.
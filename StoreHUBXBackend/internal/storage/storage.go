@@ -1,9 +1,192 @@
 package storage
 
-import "context"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
 
+// ErrNotFound is returned when an object does not exist.
+var ErrNotFound = errors.New("object not found")
+
+// ObjectInfo describes a stored object.
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+	Metadata     map[string]string // user metadata; keys are lowercase
+}
+
+// Uploader is the object store shared by the API, the worker and the
+// maintenance tools. Keys are slash-separated paths such as
+// components/<slug>/<version>/index.html.
 type Uploader interface {
 	Put(ctx context.Context, key string, data []byte, contentType string) (string, error)
 	PutFile(ctx context.Context, key string, localPath string, contentType string) (string, error)
 	PublishComponentFromDist(ctx context.Context, component, version, distDir string) (string, error)
+
+	// Get returns an object's content, or ErrNotFound.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Stat returns an object's info, or ErrNotFound.
+	Stat(ctx context.Context, key string) (ObjectInfo, error)
+	// List returns every object whose key starts with prefix, sorted by key.
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+	// Delete removes one object; deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	// DeletePrefix removes every object whose key starts with prefix and returns how many were deleted.
+	DeletePrefix(ctx context.Context, prefix string) (int, error)
+	// Copy duplicates src to dst together with its content type and metadata.
+	Copy(ctx context.Context, src, dst string) error
+	// SetMetadata replaces an object's content type (unless empty) and user
+	// metadata (unless nil) without rewriting its content.
+	SetMetadata(ctx context.Context, key, contentType string, metadata map[string]string) error
+
+	// PublicURL is the URL key is served on.
+	PublicURL(key string) string
+	// PresignGet returns a URL granting read access to key for expiry, even
+	// when the store is private.
+	PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// NewFromEnv creates the backend selected by STORAGE_BACKEND:
+//   - "s3" (default): S3Uploader, configured by the S3_* variables
+//   - "fs": FSUploader under STORAGE_FS_ROOT (default ./data/storage), with
+//     public URLs under STORAGE_PUBLIC_BASE_URL (default http://localhost:8080/static)
+func NewFromEnv() (Uploader, error) {
+	switch backend := strings.ToLower(os.Getenv("STORAGE_BACKEND")); backend {
+	case "", "s3":
+		return NewS3Uploader()
+	case "fs":
+		root := os.Getenv("STORAGE_FS_ROOT")
+		if root == "" {
+			root = "./data/storage"
+		}
+		public := os.Getenv("STORAGE_PUBLIC_BASE_URL")
+		if public == "" {
+			public = "http://localhost:8080/static"
+		}
+		return NewFSUploader(root, public)
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_BACKEND %q (want s3 or fs)", backend)
+	}
+}
+
+// publishDist publishes dist through u.Put and u.PutFile with the key
+// layout of S3Uploader.PublishComponentFromDist, for backends without
+// anything faster.
+func publishDist(ctx context.Context, u Uploader, component, version, distDir string) (string, error) {
+	info, err := os.Stat(distDir)
+	if err != nil {
+		return "", fmt.Errorf("dist directory error: %w", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("dist path is not a directory: %s", distDir)
+	}
+
+	indexBytes, err := os.ReadFile(filepath.Join(distDir, "index.html"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read index.html from dist: %w", err)
+	}
+	rewrittenIndex, _, err := rewriteIndexHTMLPaths(indexBytes, distDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to rewrite index.html: %w", err)
+	}
+
+	prefix := path.Join("components", component, version)
+	assetsLocal := filepath.Join(distDir, "assets")
+	err = filepath.WalkDir(distDir, func(p string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(distDir, p)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			// only assets/ is published recursively; other folders are skipped
+			if p != distDir && filepath.Clean(p) != filepath.Clean(assetsLocal) && !strings.HasPrefix(p, assetsLocal+string(os.PathSeparator)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if rel == "index.html" {
+			return nil
+		}
+		key := path.Join(prefix, filepath.ToSlash(rel))
+		if _, err := u.PutFile(ctx, key, p, ""); err != nil {
+			return fmt.Errorf("upload %s -> %s: %w", p, key, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("error uploading dist: %w", err)
+	}
+
+	return u.Put(ctx, path.Join(prefix, "index.html"), rewrittenIndex, "text/html")
+}
+
+// ContentTypeFix is an object whose stored content type differs from the
+// one its extension maps to.
+type ContentTypeFix struct {
+	Key  string `json:"key"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// StaleContentTypes lists the objects under prefix that FixContentTypes
+// would change, without changing them. Objects with unknown extensions are
+// left out.
+func StaleContentTypes(ctx context.Context, u Uploader, prefix string) ([]ContentTypeFix, error) {
+	objects, err := u.List(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("list objects: %w", err)
+	}
+	var stale []ContentTypeFix
+	for _, obj := range objects {
+		ct := detectContentTypeFromExt(obj.Key)
+		if ct == "" {
+			continue
+		}
+		current := obj.ContentType
+		if current == "" {
+			// some stores do not return content types when listing
+			info, err := u.Stat(ctx, obj.Key)
+			if err != nil {
+				return stale, fmt.Errorf("%s: %w", obj.Key, err)
+			}
+			current = info.ContentType
+		}
+		if !strings.EqualFold(current, ct) {
+			stale = append(stale, ContentTypeFix{Key: obj.Key, From: current, To: ct})
+		}
+	}
+	return stale, nil
+}
+
+// FixContentTypes gives every object under prefix the content type its
+// extension maps to, for objects uploaded before the type tables existed.
+// It returns the keys that were changed.
+func FixContentTypes(ctx context.Context, u Uploader, prefix string) ([]string, error) {
+	stale, err := StaleContentTypes(ctx, u, prefix)
+	if err != nil {
+		return nil, err
+	}
+	var fixed []string
+	for _, fix := range stale {
+		if err := u.SetMetadata(ctx, fix.Key, fix.To, nil); err != nil {
+			return fixed, fmt.Errorf("%s: %w", fix.Key, err)
+		}
+		fixed = append(fixed, fix.Key)
+	}
+	return fixed, nil
 }
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxSlugLength caps the length of a component slug (excluding any @scope/ prefix).
+const MaxSlugLength = 64
+
+var (
+	ErrEmptySlug    = errors.New("name must contain at least one letter or digit")
+	ErrReservedSlug = errors.New("name is reserved")
+)
+
+// reservedSlugs collide with UI or API routes (e.g. /components/new).
+var reservedSlugs = map[string]bool{
+	"new": true, "edit": true, "import": true, "settings": true,
+	"api": true, "admin": true, "auth": true, "me": true,
+	"components": true, "users": true, "builds": true, "preview": true,
+	"static": true, "docs": true, "health": true, "webhooks": true,
+	"latest": true, "null": true, "undefined": true,
+}
+
+// letters that do not decompose under NFKD but have an obvious ASCII spelling
+var foldReplacements = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'Æ': "ae", 'ø': "o", 'Ø': "o",
+	'œ': "oe", 'Œ': "oe", 'ł': "l", 'Ł': "l", 'đ': "d", 'Đ': "d",
+	'þ': "th", 'Þ': "th", 'ı': "i",
+}
+
+// Slugify turns a free-form name into a URL-safe slug: accents are folded to
+// ASCII, anything that is not a letter or digit becomes a single hyphen, and
+// the result is trimmed to MaxSlugLength.
+func Slugify(name string) string {
+	var b strings.Builder
+	pendingHyphen := false
+	for _, r := range norm.NFKD.String(name) {
+		if unicode.Is(unicode.Mn, r) {
+			continue // combining accent left over from decomposition
+		}
+		s := string(unicode.ToLower(r))
+		if rep, ok := foldReplacements[r]; ok {
+			s = rep
+		}
+		for _, ch := range s {
+			if (ch >= 'a' && ch <= 'z') || (ch >= '0' && ch <= '9') {
+				if pendingHyphen && b.Len() > 0 {
+					b.WriteByte('-')
+				}
+				pendingHyphen = false
+				b.WriteRune(ch)
+			} else {
+				pendingHyphen = true
+			}
+		}
+	}
+
+	slug := b.String()
+	if len(slug) > MaxSlugLength {
+		slug = slug[:MaxSlugLength]
+		// prefer cutting on a word boundary when one is reasonably close
+		if i := strings.LastIndexByte(slug, '-'); i > MaxSlugLength/2 {
+			slug = slug[:i]
+		}
+		slug = strings.TrimRight(slug, "-")
+	}
+	return slug
+}
+
+// ValidateSlug checks a slug produced by Slugify for emptiness and reserved words.
+func ValidateSlug(slug string) error {
+	if slug == "" {
+		return ErrEmptySlug
+	}
+	if reservedSlugs[slug] {
+		return ErrReservedSlug
+	}
+	return nil
+}
+
+// ScopedSlug builds the owner-namespaced form "@scope/slug".
+func ScopedSlug(scope, slug string) string {
+	return "@" + scope + "/" + slug
+}
@@ -0,0 +1,62 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rishyym0927/storehubx/internal/models"
+	"github.com/rishyym0927/storehubx/internal/semver"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// promoteLatest points the component's "latest" dist-tag at a freshly built
+// stable version, unless the tag already names a higher one (an older
+// version rebuilt, or an owner who pinned latest ahead) or the version has
+// been yanked.
+func (p *Processor) promoteLatest(ctx context.Context, job *models.BuildJob) {
+	if job.PullRequest != nil {
+		return
+	}
+	built, err := semver.Parse(job.Version)
+	if err != nil || built.IsPrerelease() {
+		return
+	}
+
+	verCol := p.collection("component_versions")
+	if verCol == nil {
+		return
+	}
+	// A version yanked while it was building stays out of resolution.
+	if err := verCol.FindOne(ctx, bson.M{
+		"componentId": job.ComponentID,
+		"version":     job.Version,
+		"yanked":      bson.M{"$ne": true},
+	}).Err(); err != nil {
+		return
+	}
+
+	col := p.collection("components")
+	var comp models.Component
+	if err := col.FindOne(ctx, bson.M{"_id": job.ComponentID}).Decode(&comp); err != nil {
+		return
+	}
+
+	filter := bson.M{"_id": comp.ID}
+	if current, ok := comp.DistTags[models.DistTagLatest]; ok {
+		if cur, err := semver.Parse(current); err == nil && !cur.LessThan(built) {
+			return
+		}
+		filter["distTags."+models.DistTagLatest] = current // lose quietly to a concurrent move
+	} else {
+		filter["distTags."+models.DistTagLatest] = bson.M{"$exists": false}
+	}
+
+	res, err := col.UpdateOne(ctx, filter, bson.M{"$set": bson.M{
+		"distTags." + models.DistTagLatest: job.Version,
+		"updatedAt":                        time.Now(),
+	}})
+	if err == nil && res.ModifiedCount > 0 {
+		p.logPush(ctx, job.ID, fmt.Sprintf("latest -> %s", job.Version))
+	}
+}
@@ -3,13 +3,16 @@ package worker
 import (
 	"archive/zip"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/rishyym0927/storehubx/internal/buildlog"
 	"github.com/rishyym0927/storehubx/internal/models"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -58,75 +61,36 @@ func unzip(srcZip, destDir string) (string, error) {
 	return topDir, nil
 }
 
-func (p *Processor) maybeBuildWithNode(ctx context.Context, jobID primitive.ObjectID, workingDir string) error {
-	// if package.json exists, try a standard build
-	if _, err := os.Stat(filepath.Join(workingDir, "package.json")); err == nil {
-		cmds := [][]string{
-			{"npm", "ci"},
-			{"npm", "run", "build"},
+// maybeBuildWithNode runs the install and build steps. Projects without a
+// package.json skip install; the build step then only checks for a static
+// index.html to publish.
+func (p *Processor) maybeBuildWithNode(ctx context.Context, pl *pipeline, workingDir string, env []string) error {
+	_, err := os.Stat(filepath.Join(workingDir, "package.json"))
+	hasPackage := err == nil
+
+	if hasPackage {
+		pl.start(ctx, models.StepInstall)
+		if err := p.runStep(ctx, pl.jobID, models.StepInstall, workingDir, env, []string{"npm", "ci"}); err != nil {
+			return err
 		}
-		for _, c := range cmds {
-			cmd := exec.Command(c[0], c[1:]...)
-			cmd.Dir = workingDir
-
-			// Create pipes for capturing stdout and stderr
-			outPipe, _ := cmd.StdoutPipe()
-			errPipe, _ := cmd.StderrPipe()
+		pl.finish(ctx, nil)
+	} else {
+		pl.skip(ctx, models.StepInstall)
+	}
 
-			// Start the command
-			if err := cmd.Start(); err != nil {
-				return fmt.Errorf("failed to start command %v: %w", c, err)
-			}
+	pl.start(ctx, models.StepBuild)
+	if err := p.buildOutput(ctx, pl.jobID, workingDir, env, hasPackage); err != nil {
+		return err
+	}
+	pl.finish(ctx, nil)
+	return nil
+}
 
-			// Create a channel to signal when the reading is done
-			done := make(chan bool)
-
-			// Capture stdout in a goroutine
-			go func() {
-				buf := make([]byte, 1024)
-				for {
-					n, err := outPipe.Read(buf)
-					if n > 0 {
-						output := string(buf[:n])
-						fmt.Print(output) // Still print to console
-
-						// Now properly log to database
-						p.logPush(ctx, jobID, fmt.Sprintf("[%s] %s", c[0], strings.TrimSpace(output)))
-					}
-					if err != nil {
-						break
-					}
-				}
-				done <- true
-			}()
-
-			// Capture stderr in another goroutine
-			go func() {
-				buf := make([]byte, 1024)
-				for {
-					n, err := errPipe.Read(buf)
-					if n > 0 {
-						output := string(buf[:n])
-						fmt.Print(output) // Still print to console
-
-						// Now properly log to database
-						p.logPush(ctx, jobID, fmt.Sprintf("[%s ERROR] %s", c[0], strings.TrimSpace(output)))
-					}
-					if err != nil {
-						break
-					}
-				}
-				done <- true
-			}()
-
-			// Wait for both stdout and stderr to be fully read
-			<-done
-			<-done
-
-			// Wait for the command to finish
-			if err := cmd.Wait(); err != nil {
-				return fmt.Errorf("node build failed on %v: %w", c, err)
-			}
+func (p *Processor) buildOutput(ctx context.Context, jobID primitive.ObjectID, workingDir string, env []string, hasPackage bool) error {
+	// if package.json exists, try a standard build
+	if hasPackage {
+		if err := p.runStep(ctx, jobID, models.StepBuild, workingDir, env, []string{"npm", "run", "build"}); err != nil {
+			return err
 		}
 		// prefer dist/ or build/ as output
 		if _, err := os.Stat(filepath.Join(workingDir, "dist")); err == nil {
@@ -144,6 +108,47 @@ func (p *Processor) maybeBuildWithNode(ctx context.Context, jobID primitive.Obje
 	return fmt.Errorf("no build output found (need package.json+build or index.html)")
 }
 
+// runStep runs one build command, streaming its output into the job log. The
+// command gets p.stepTimeout of wall-clock time; on timeout or cancellation its
+// whole process group is killed so stray children (node, esbuild...) die too.
+func (p *Processor) runStep(ctx context.Context, jobID primitive.ObjectID, step, workingDir string, env []string, c []string) error {
+	stepCtx, cancel := context.WithTimeout(ctx, p.stepTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(stepCtx, c[0], c[1:]...)
+	cmd.Dir = workingDir
+	cmd.Env = append(os.Environ(), env...)
+	killProcessGroupOnCancel(cmd)
+	// don't let a grandchild holding the pipes open block Wait forever
+	cmd.WaitDelay = 10 * time.Second
+
+	// Output is stored line by line; log writes must outlive an aborted step
+	// so the last lines before a kill are kept.
+	logCtx := context.WithoutCancel(ctx)
+	stdout := p.newLogWriter(logCtx, jobID, buildlog.Stdout, step)
+	stderr := p.newLogWriter(logCtx, jobID, buildlog.Stderr, step)
+	cmd.Stdout = io.MultiWriter(os.Stdout, stdout) // still print to console
+	cmd.Stderr = io.MultiWriter(os.Stderr, stderr)
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start command %v: %w", c, err)
+	}
+	err := cmd.Wait()
+	stdout.Flush()
+	stderr.Flush()
+
+	if err != nil {
+		if errors.Is(stepCtx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("node build step %v timed out after %s", c, p.stepTimeout)
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("node build step %v aborted: %w", c, context.Cause(ctx))
+		}
+		return fmt.Errorf("node build failed on %v: %w", c, err)
+	}
+	return nil
+}
+
 func pickOutputDir(workingDir string) (string, error) {
 	for _, cand := range []string{"dist", "build", "."} {
 		p := filepath.Join(workingDir, cand)
@@ -14,7 +14,45 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 )
 
+// Source fetches the repository zip a job builds from into destDir and
+// returns the path of the zip file.
+type Source func(ctx context.Context, job *models.BuildJob, destDir string) (string, error)
+
+// GitHubSource downloads the job's ref as a zipball from GitHub, with the
+// owner's token so private repositories work. It is the default Source.
+func GitHubSource(ctx context.Context, job *models.BuildJob, destDir string) (string, error) {
+	return downloadRepoZip(ctx, destDir, job.Repo.Owner, job.Repo.Repo, firstNonEmpty(job.Repo.Commit, job.Repo.Ref, "main"), job.OwnerID)
+}
+
+// ZipFileSource builds every job from a local zip laid out like a GitHub
+// zipball (one top-level folder holding the repository).
+func ZipFileSource(zipPath string) Source {
+	return func(ctx context.Context, job *models.BuildJob, destDir string) (string, error) {
+		src, err := os.Open(zipPath)
+		if err != nil {
+			return "", err
+		}
+		defer src.Close()
+		if err := os.MkdirAll(destDir, 0o755); err != nil {
+			return "", err
+		}
+		localZip := filepath.Join(destDir, "repo.zip")
+		out, err := os.Create(localZip)
+		if err != nil {
+			return "", err
+		}
+		defer out.Close()
+		if _, err := io.Copy(out, src); err != nil {
+			return "", err
+		}
+		return localZip, out.Close()
+	}
+}
+
 func fetchUserDecryptedToken(ctx context.Context, ownerID string) (string, error) {
+	if db.Client == nil {
+		return "", fmt.Errorf("no database to look up the token of %s", ownerID)
+	}
 	// ownerID == ProviderID (from JWT/user_id)
 	var user models.User
 	if err := db.Client.Database(os.Getenv("MONGO_DB")).
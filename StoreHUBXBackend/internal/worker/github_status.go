@@ -0,0 +1,110 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	githubapi "github.com/rishyym0927/storehubx/internal/github"
+	"github.com/rishyym0927/storehubx/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// GitHub commit status states.
+const (
+	statusPending = "pending"
+	statusSuccess = "success"
+	statusFailure = "failure"
+	statusError   = "error"
+)
+
+// reportStatus posts a commit status for the job's commit as the job owner.
+// Problems are only logged: GitHub being unreachable must not fail a build.
+// A job without a pinned commit gets its ref resolved here and the result is
+// stored on the job, so the build, a retry and the reaper all report on what
+// was actually built even if the branch moves on.
+func (p *Processor) reportStatus(ctx context.Context, job *models.BuildJob, state, description, targetURL string) {
+	if !p.commitStatus || job.Repo.Owner == "" || job.Repo.Repo == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	token, err := fetchUserDecryptedToken(ctx, job.OwnerID)
+	if err != nil {
+		fmt.Printf("[WORKER] job %s: no token for commit status: %v\n", job.ID.Hex(), err)
+		return
+	}
+	gh := githubapi.NewClient(token)
+
+	if job.Repo.Commit == "" {
+		sha, err := gh.ResolveCommit(ctx, job.Repo.Owner, job.Repo.Repo, firstNonEmpty(job.Repo.Ref, "HEAD"))
+		if err != nil {
+			fmt.Printf("[WORKER] job %s: could not resolve %q for commit status: %v\n", job.ID.Hex(), job.Repo.Ref, err)
+			return
+		}
+		job.Repo.Commit = sha
+		p.pinCommit(ctx, job)
+	}
+
+	err = gh.CreateStatus(ctx, job.Repo.Owner, job.Repo.Repo, job.Repo.Commit, githubapi.CommitStatus{
+		State:       state,
+		TargetURL:   targetURL,
+		Description: description,
+		Context:     "storehubx/" + job.Component,
+	})
+	if err != nil {
+		fmt.Printf("[WORKER] job %s: could not post commit status: %v\n", job.ID.Hex(), err)
+	}
+}
+
+// pinCommit stores the commit resolved for a job that was queued without one.
+// A commit pinned earlier is left alone.
+func (p *Processor) pinCommit(ctx context.Context, job *models.BuildJob) {
+	jobs := p.collection("build_jobs")
+	if jobs == nil {
+		return
+	}
+	_, err := jobs.UpdateOne(ctx,
+		bson.M{"_id": job.ID, "repo.commit": bson.M{"$in": bson.A{"", nil}}},
+		bson.M{"$set": bson.M{"repo.commit": job.Repo.Commit}},
+	)
+	if err != nil {
+		fmt.Printf("[WORKER] job %s: could not pin commit %s: %v\n", job.ID.Hex(), job.Repo.Commit, err)
+	}
+}
+
+// commentPreview keeps the preview comment on a pull request build up to date;
+// it does nothing for regular builds.
+func (p *Processor) commentPreview(ctx context.Context, job *models.BuildJob, body string) {
+	if job.PullRequest == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	token, err := fetchUserDecryptedToken(ctx, job.OwnerID)
+	if err != nil {
+		fmt.Printf("[WORKER] job %s: no token for preview comment: %v\n", job.ID.Hex(), err)
+		return
+	}
+	err = githubapi.NewClient(token).UpsertIssueComment(ctx, job.Repo.Owner, job.Repo.Repo, job.PullRequest.Number,
+		models.PreviewCommentMarker(job.Component), body)
+	if err != nil {
+		fmt.Printf("[WORKER] job %s: could not comment on pull request #%d: %v\n", job.ID.Hex(), job.PullRequest.Number, err)
+	}
+}
+
+// logTarget links a failed build's status to its archived log when there is one.
+func (p *Processor) logTarget(job *models.BuildJob, final bson.M) string {
+	if u, ok := final["artifacts.logUrl"].(string); ok {
+		return u
+	}
+	return p.componentPage(job)
+}
+
+// componentPage is the dashboard page used as status target while no preview exists.
+func (p *Processor) componentPage(job *models.BuildJob) string {
+	return p.frontendURL + "/components/" + url.PathEscape(job.Component)
+}
@@ -0,0 +1,83 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/rishyym0927/storehubx/internal/buildlog"
+	"github.com/rishyym0927/storehubx/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// logWriter is an io.Writer that stores a command's output as log lines.
+// Partial lines are buffered until their newline arrives or Flush is called.
+type logWriter struct {
+	ctx    context.Context
+	p      *Processor
+	jobID  primitive.ObjectID
+	stream buildlog.Stream
+	step   string
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (p *Processor) newLogWriter(ctx context.Context, jobID primitive.ObjectID, stream buildlog.Stream, step string) *logWriter {
+	return &logWriter{ctx: ctx, p: p, jobID: jobID, stream: stream, step: step}
+}
+
+func (w *logWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, b...)
+	cut := bytes.LastIndexByte(w.buf, '\n') + 1
+	if cut == 0 && len(w.buf) >= buildlog.MaxLineLength {
+		cut = len(w.buf) // no newline in sight (progress bars); store it anyway
+	}
+	if cut > 0 {
+		w.append(string(w.buf[:cut]))
+		w.buf = append(w.buf[:0], w.buf[cut:]...)
+	}
+	// losing a log line must never fail the build
+	return len(b), nil
+}
+
+// Flush stores whatever is left of an unterminated last line.
+func (w *logWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) > 0 {
+		w.append(string(w.buf))
+		w.buf = w.buf[:0]
+	}
+}
+
+func (w *logWriter) append(text string) {
+	if err := w.p.logs.Append(w.ctx, w.jobID, w.stream, w.step, text); err != nil {
+		fmt.Printf("[WORKER] job %s: could not store log output: %v\n", w.jobID.Hex(), err)
+	}
+}
+
+// archiveLogs uploads the job's full log as a text file and returns its URL,
+// or "" when the upload fails (the lines stay readable from the log store).
+func (p *Processor) archiveLogs(ctx context.Context, job *models.BuildJob) string {
+	var buf bytes.Buffer
+	if _, err := p.logs.WriteTo(ctx, job.ID, &buf); err != nil {
+		fmt.Printf("[WORKER] job %s: could not read log for archiving: %v\n", job.ID.Hex(), err)
+		return ""
+	}
+	url, err := p.uploader.Put(ctx, LogArchiveKey(job.ID), buf.Bytes(), "text/plain; charset=utf-8")
+	if err != nil {
+		fmt.Printf("[WORKER] job %s: could not archive log: %v\n", job.ID.Hex(), err)
+		return ""
+	}
+	return url
+}
+
+// LogArchiveKey is where a finished job's full log is stored in the bucket.
+func LogArchiveKey(jobID primitive.ObjectID) string {
+	return "build-logs/" + jobID.Hex() + ".log"
+}
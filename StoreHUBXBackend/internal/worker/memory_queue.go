@@ -0,0 +1,230 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rishyym0927/storehubx/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryQueue is a Queue kept in memory, in FIFO order. It backs processors
+// running without a database and lets callers enqueue jobs and inspect how
+// they ended.
+type MemoryQueue struct {
+	maxAttempts int
+
+	mu   sync.Mutex
+	jobs []*models.BuildJob // in enqueue order
+}
+
+// NewMemoryQueue returns an empty queue; maxAttempts is stored on jobs that
+// do not carry their own limit (default 3).
+func NewMemoryQueue(maxAttempts int) *MemoryQueue {
+	if maxAttempts <= 0 {
+		maxAttempts = 3
+	}
+	return &MemoryQueue{maxAttempts: maxAttempts}
+}
+
+// Enqueue adds a queued copy of job and returns its ID, assigning one when
+// the job has none.
+func (q *MemoryQueue) Enqueue(job models.BuildJob) primitive.ObjectID {
+	if job.ID.IsZero() {
+		job.ID = primitive.NewObjectID()
+	}
+	now := time.Now()
+	job.Status = models.BuildQueued
+	if job.CreatedAt.IsZero() {
+		job.CreatedAt = now
+	}
+	job.UpdatedAt = now
+
+	q.mu.Lock()
+	q.jobs = append(q.jobs, &job)
+	q.mu.Unlock()
+	return job.ID
+}
+
+// Job returns a copy of the job with the given ID.
+func (q *MemoryQueue) Job(id primitive.ObjectID) (models.BuildJob, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if job := q.find(id); job != nil {
+		return *job, true
+	}
+	return models.BuildJob{}, false
+}
+
+// Cancel asks the worker running the job to stop, like the cancel endpoint.
+func (q *MemoryQueue) Cancel(id primitive.ObjectID) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job := q.find(id)
+	if job == nil || job.Status != models.BuildRunning {
+		return false
+	}
+	job.CancelRequested = true
+	return true
+}
+
+func (q *MemoryQueue) Claim(ctx context.Context, owner string, lease time.Duration) (*models.BuildJob, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, job := range q.jobs {
+		if job.Status != models.BuildQueued {
+			continue
+		}
+		now := time.Now()
+		expires := now.Add(lease)
+		job.Status = models.BuildRunning
+		job.StartedAt, job.UpdatedAt, job.HeartbeatAt = &now, now, &now
+		job.LeaseOwner, job.LeaseExpiresAt = owner, &expires
+		job.Attempts++
+		if job.MaxAttempts <= 0 {
+			job.MaxAttempts = q.maxAttempts
+		}
+		claimed := *job
+		return &claimed, nil
+	}
+	return nil, nil
+}
+
+func (q *MemoryQueue) Heartbeat(ctx context.Context, id primitive.ObjectID, owner string, lease time.Duration) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job := q.find(id)
+	if job == nil || job.Status != models.BuildRunning || job.LeaseOwner != owner {
+		return ErrLeaseLost
+	}
+	now := time.Now()
+	expires := now.Add(lease)
+	job.LeaseExpiresAt, job.HeartbeatAt = &expires, &now
+	if job.CancelRequested {
+		return ErrCancelRequested
+	}
+	return nil
+}
+
+func (q *MemoryQueue) Complete(ctx context.Context, id primitive.ObjectID, owner string, status models.BuildStatus, extra bson.M) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job := q.find(id)
+	if job == nil || job.LeaseOwner != owner {
+		return ErrLeaseLost
+	}
+	set := bson.M{"status": status, "updatedAt": time.Now()}
+	for k, v := range extra {
+		set[k] = v
+	}
+	if err := applySet(job, set); err != nil {
+		return err
+	}
+	job.LeaseOwner, job.LeaseExpiresAt = "", nil
+	return nil
+}
+
+func (q *MemoryQueue) Release(ctx context.Context, id primitive.ObjectID, owner string, reason string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job := q.find(id)
+	if job == nil || job.Status != models.BuildRunning || job.LeaseOwner != owner {
+		return ErrLeaseLost
+	}
+	job.Status, job.UpdatedAt = models.BuildQueued, time.Now()
+	job.LeaseOwner, job.LeaseExpiresAt = "", nil
+	job.Attempts--
+	return nil
+}
+
+func (q *MemoryQueue) ReapExpired(ctx context.Context) ([]ReapedJob, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := time.Now()
+	var reaped []ReapedJob
+	for _, job := range q.jobs {
+		if job.Status != models.BuildRunning || job.LeaseExpiresAt == nil || !job.LeaseExpiresAt.Before(now) {
+			continue
+		}
+		before := *job
+		requeue := job.Attempts < job.MaxAttempts && !job.CancelRequested
+		switch {
+		case job.CancelRequested:
+			job.Status, job.EndedAt = models.BuildCanceled, &now
+		case requeue:
+			job.Status = models.BuildQueued
+		default:
+			job.Status, job.EndedAt = models.BuildError, &now
+		}
+		job.UpdatedAt = now
+		job.LeaseOwner, job.LeaseExpiresAt = "", nil
+		reaped = append(reaped, ReapedJob{Job: before, Requeued: requeue})
+	}
+	return reaped, nil
+}
+
+func (q *MemoryQueue) find(id primitive.ObjectID) *models.BuildJob {
+	for _, job := range q.jobs {
+		if job.ID == id {
+			return job
+		}
+	}
+	return nil
+}
+
+// applySet applies a MongoDB $set document, dotted paths included, to job by
+// round-tripping it through BSON, so Complete accepts the same fields the
+// worker sends to MongoQueue.
+func applySet(job *models.BuildJob, set bson.M) error {
+	raw, err := bson.Marshal(job)
+	if err != nil {
+		return err
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return err
+	}
+	for key, v := range set {
+		parts := strings.Split(key, ".")
+		parent := doc
+		for _, part := range parts[:len(parts)-1] {
+			child, err := subdocument(parent[part])
+			if err != nil {
+				return fmt.Errorf("set %s: %w", key, err)
+			}
+			parent[part] = child
+			parent = child
+		}
+		parent[parts[len(parts)-1]] = v
+	}
+	if raw, err = bson.Marshal(doc); err != nil {
+		return err
+	}
+	var out models.BuildJob
+	if err := bson.Unmarshal(raw, &out); err != nil {
+		return err
+	}
+	*job = out
+	return nil
+}
+
+// subdocument returns v as an editable document, creating one for nil.
+func subdocument(v any) (bson.M, error) {
+	switch d := v.(type) {
+	case nil:
+		return bson.M{}, nil
+	case bson.M:
+		return d, nil
+	default:
+		raw, err := bson.Marshal(d)
+		if err != nil {
+			return nil, err
+		}
+		var m bson.M
+		return m, bson.Unmarshal(raw, &m)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build windows
+
+package worker
+
+import "os/exec"
+
+// killProcessGroupOnCancel falls back to killing only the direct child on
+// Windows, which has no POSIX process groups.
+func killProcessGroupOnCancel(cmd *exec.Cmd) {
+	cmd.Cancel = func() error {
+		return cmd.Process.Kill()
+	}
+}
@@ -0,0 +1,292 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rishyym0927/storehubx/internal/buildlog"
+	"github.com/rishyym0927/storehubx/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrLeaseLost is returned when a worker touches a job it no longer holds the
+// lease for (the lease expired and the reaper gave the job to someone else).
+var ErrLeaseLost = errors.New("job lease lost")
+
+// ErrCancelRequested is returned by Heartbeat when the job's owner asked to cancel it.
+var ErrCancelRequested = errors.New("build canceled by user")
+
+// Queue hands build jobs to workers. A claimed job is leased to one worker for
+// a limited time; the worker renews the lease with Heartbeat while it builds,
+// and jobs whose lease runs out are recovered by ReapExpired.
+type Queue interface {
+	// Claim leases the oldest queued job to owner, a token unique to this
+	// claim that later calls must present. Returns (nil, nil) when nothing is queued.
+	Claim(ctx context.Context, owner string, lease time.Duration) (*models.BuildJob, error)
+	// Heartbeat extends owner's lease on a running job. It returns
+	// ErrCancelRequested once the job has been canceled.
+	Heartbeat(ctx context.Context, id primitive.ObjectID, owner string, lease time.Duration) error
+	// Complete records the final status of a leased job and drops the lease.
+	Complete(ctx context.Context, id primitive.ObjectID, owner string, status models.BuildStatus, extra bson.M) error
+	// Release puts a leased job back in the queue without counting the claim as
+	// an attempt (used when a worker shuts down mid-build).
+	Release(ctx context.Context, id primitive.ObjectID, owner string, reason string) error
+	// ReapExpired requeues running jobs whose lease expired, or fails them once
+	// they have used up their attempts.
+	ReapExpired(ctx context.Context) ([]ReapedJob, error)
+}
+
+// ReapedJob describes what the reaper did with an expired job.
+type ReapedJob struct {
+	Job      models.BuildJob
+	Requeued bool // false means it was marked as error (out of attempts) or canceled
+}
+
+// MongoQueue is the default Queue backed by the build_jobs collection.
+type MongoQueue struct {
+	col *mongo.Collection
+	cfg MongoQueueConfig
+}
+
+type MongoQueueConfig struct {
+	// MaxAttempts is stored on jobs that do not carry their own limit.
+	MaxAttempts int
+	// StaleAfter is how long a running job without any lease (claimed by a
+	// pre-lease worker) may go without updates before it is considered dead.
+	StaleAfter time.Duration
+	// MaxRunningPerOwner caps how many of one owner's jobs may run at once
+	// across all workers. Zero means no hard cap (fair ordering still applies).
+	MaxRunningPerOwner int
+	// Logs receives the queue's own messages (requeues, reaped leases). Optional.
+	Logs *buildlog.Store
+}
+
+func NewMongoQueue(col *mongo.Collection, cfg MongoQueueConfig) *MongoQueue {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 3
+	}
+	if cfg.StaleAfter <= 0 {
+		cfg.StaleAfter = 10 * time.Minute
+	}
+	return &MongoQueue{col: col, cfg: cfg}
+}
+
+// Claim prefers the oldest job of an owner that has nothing running yet, so one
+// user enqueuing many versions cannot starve everyone else. When every queued
+// job belongs to a busy owner it falls back to plain FIFO (minus owners at the cap).
+func (q *MongoQueue) Claim(ctx context.Context, owner string, lease time.Duration) (*models.BuildJob, error) {
+	running, err := q.runningPerOwner(ctx)
+	if err != nil {
+		return nil, err
+	}
+	busy := make([]string, 0, len(running))
+	atCap := make([]string, 0)
+	for ownerID, n := range running {
+		busy = append(busy, ownerID)
+		if q.cfg.MaxRunningPerOwner > 0 && n >= q.cfg.MaxRunningPerOwner {
+			atCap = append(atCap, ownerID)
+		}
+	}
+
+	job, err := q.claimWhere(ctx, bson.M{"status": models.BuildQueued, "ownerId": bson.M{"$nin": busy}}, owner, lease)
+	if job != nil || err != nil || len(busy) == 0 {
+		return job, err
+	}
+	return q.claimWhere(ctx, bson.M{"status": models.BuildQueued, "ownerId": bson.M{"$nin": atCap}}, owner, lease)
+}
+
+// runningPerOwner counts running jobs grouped by ownerId.
+func (q *MongoQueue) runningPerOwner(ctx context.Context) (map[string]int, error) {
+	cur, err := q.col.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"status": models.BuildRunning}}},
+		{{Key: "$group", Value: bson.M{"_id": "$ownerId", "n": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		Owner string `bson:"_id"`
+		N     int    `bson:"n"`
+	}
+	if err := cur.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	out := make(map[string]int, len(rows))
+	for _, r := range rows {
+		out[r.Owner] = r.N
+	}
+	return out, nil
+}
+
+func (q *MongoQueue) claimWhere(ctx context.Context, filter bson.M, owner string, lease time.Duration) (*models.BuildJob, error) {
+	now := time.Now()
+	// pipeline update so attempts/maxAttempts can be derived from the stored values
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"status":         models.BuildRunning,
+			"startedAt":      now,
+			"updatedAt":      now,
+			"leaseOwner":     owner,
+			"leaseExpiresAt": now.Add(lease),
+			"heartbeatAt":    now,
+			"attempts":       bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$attempts", 0}}, 1}},
+			"maxAttempts":    bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{"$maxAttempts", 0}}, "$maxAttempts", q.cfg.MaxAttempts}},
+		}}},
+	}
+
+	var job models.BuildJob
+	err := q.col.FindOneAndUpdate(ctx,
+		filter,
+		update,
+		options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "createdAt", Value: 1}}).
+			SetReturnDocument(options.After),
+	).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (q *MongoQueue) Heartbeat(ctx context.Context, id primitive.ObjectID, owner string, lease time.Duration) error {
+	now := time.Now()
+	var job struct {
+		CancelRequested bool `bson:"cancelRequested"`
+	}
+	err := q.col.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "status": models.BuildRunning, "leaseOwner": owner},
+		bson.M{"$set": bson.M{"leaseExpiresAt": now.Add(lease), "heartbeatAt": now}},
+		options.FindOneAndUpdate().SetProjection(bson.M{"cancelRequested": 1}),
+	).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrLeaseLost
+	}
+	if err != nil {
+		return err
+	}
+	if job.CancelRequested {
+		return ErrCancelRequested
+	}
+	return nil
+}
+
+func (q *MongoQueue) Complete(ctx context.Context, id primitive.ObjectID, owner string, status models.BuildStatus, extra bson.M) error {
+	set := bson.M{"status": status, "updatedAt": time.Now()}
+	for k, v := range extra {
+		set[k] = v
+	}
+	res, err := q.col.UpdateOne(ctx,
+		bson.M{"_id": id, "leaseOwner": owner},
+		bson.M{"$set": set, "$unset": bson.M{"leaseOwner": "", "leaseExpiresAt": ""}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+func (q *MongoQueue) Release(ctx context.Context, id primitive.ObjectID, owner string, reason string) error {
+	res, err := q.col.UpdateOne(ctx,
+		bson.M{"_id": id, "status": models.BuildRunning, "leaseOwner": owner},
+		bson.M{
+			"$set":   bson.M{"status": models.BuildQueued, "updatedAt": time.Now()},
+			"$unset": bson.M{"leaseOwner": "", "leaseExpiresAt": ""},
+			"$inc":   bson.M{"attempts": -1},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrLeaseLost
+	}
+	q.log(ctx, id, "requeued: "+reason)
+	return nil
+}
+
+func (q *MongoQueue) ReapExpired(ctx context.Context) ([]ReapedJob, error) {
+	now := time.Now()
+	expired := bson.M{
+		"status": models.BuildRunning,
+		"$or": []bson.M{
+			{"leaseExpiresAt": bson.M{"$lt": now}},
+			{"leaseExpiresAt": bson.M{"$exists": false}, "updatedAt": bson.M{"$lt": now.Add(-q.cfg.StaleAfter)}},
+		},
+	}
+
+	cur, err := q.col.Find(ctx, expired)
+	if err != nil {
+		return nil, err
+	}
+	var jobs []models.BuildJob
+	if err := cur.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+
+	reaped := make([]ReapedJob, 0, len(jobs))
+	for _, job := range jobs {
+		maxAttempts := job.MaxAttempts
+		if maxAttempts <= 0 {
+			maxAttempts = q.cfg.MaxAttempts
+		}
+		requeue := job.Attempts < maxAttempts
+
+		set := bson.M{"updatedAt": now}
+		var msg string
+		if job.CancelRequested {
+			// the owner wanted it stopped anyway; don't bring it back
+			requeue = false
+			set["status"] = models.BuildCanceled
+			set["endedAt"] = now
+			msg = "lease expired while a cancel was pending; marked as canceled"
+		} else if requeue {
+			set["status"] = models.BuildQueued
+			msg = "lease expired (worker " + orUnknown(job.LeaseOwner) + " stopped responding); requeued"
+		} else {
+			set["status"] = models.BuildError
+			set["endedAt"] = now
+			msg = "lease expired (worker " + orUnknown(job.LeaseOwner) + " stopped responding); giving up after max attempts"
+		}
+
+		// re-check the expiry condition so a late heartbeat wins over the reaper
+		filter := bson.M{"_id": job.ID}
+		for k, v := range expired {
+			filter[k] = v
+		}
+		res, err := q.col.UpdateOne(ctx, filter, bson.M{
+			"$set":   set,
+			"$unset": bson.M{"leaseOwner": "", "leaseExpiresAt": ""},
+		})
+		if err != nil {
+			return reaped, err
+		}
+		if res.ModifiedCount == 1 {
+			q.log(ctx, job.ID, msg)
+			reaped = append(reaped, ReapedJob{Job: job, Requeued: requeue})
+		}
+	}
+	return reaped, nil
+}
+
+func (q *MongoQueue) log(ctx context.Context, id primitive.ObjectID, msg string) {
+	if q.cfg.Logs != nil {
+		_ = q.cfg.Logs.Append(ctx, id, buildlog.System, "", msg)
+	}
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
@@ -0,0 +1,65 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rishyym0927/storehubx/internal/artifact"
+	"github.com/rishyym0927/storehubx/internal/models"
+)
+
+// sourceSnapshot is a packed component folder waiting for the upload step.
+type sourceSnapshot struct {
+	manifest *artifact.Manifest
+	tarball  string // local path
+}
+
+// snapshotSource packs the linked folder before install and build write
+// node_modules and build output into it.
+func (p *Processor) snapshotSource(job *models.BuildJob, working, workRoot string) (*sourceSnapshot, error) {
+	tarball := filepath.Join(workRoot, artifact.TarballName)
+	f, err := os.Create(tarball)
+	if err != nil {
+		return nil, err
+	}
+	m, err := artifact.Pack(working, f, p.sourceLimit)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+	m.Component = job.Component
+	m.Version = job.Version
+	m.Commit = job.Repo.Commit
+	m.CreatedAt = time.Now()
+	return &sourceSnapshot{manifest: m, tarball: tarball}, nil
+}
+
+// publishSource uploads the tarball and its manifest next to the preview.
+func (p *Processor) publishSource(ctx context.Context, job *models.BuildJob, snap *sourceSnapshot) (*models.SourceArtifact, error) {
+	tarURL, err := p.uploader.PutFile(ctx, artifact.TarballKey(job.Component, job.Version), snap.tarball, "application/gzip")
+	if err != nil {
+		return nil, fmt.Errorf("upload source tarball: %w", err)
+	}
+	body, err := json.MarshalIndent(snap.manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	manifestURL, err := p.uploader.Put(ctx, artifact.ManifestKey(job.Component, job.Version), body, "application/json")
+	if err != nil {
+		return nil, fmt.Errorf("upload source manifest: %w", err)
+	}
+	return &models.SourceArtifact{
+		TarballURL:  tarURL,
+		ManifestURL: manifestURL,
+		Integrity:   snap.manifest.Integrity,
+		Size:        snap.manifest.Size,
+		Files:       len(snap.manifest.Files),
+		Commit:      snap.manifest.Commit,
+	}, nil
+}
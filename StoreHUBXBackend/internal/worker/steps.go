@@ -0,0 +1,125 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rishyym0927/storehubx/internal/buildlog"
+	"github.com/rishyym0927/storehubx/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// pipeline tracks a job's progress through models.BuildStepNames and mirrors
+// it to the job's steps field after every transition.
+type pipeline struct {
+	p       *Processor
+	jobID   primitive.ObjectID
+	lease   string // the job's lease token; saves only land while it holds
+	steps   []models.BuildStep
+	current int // index of the running step, -1 if none
+}
+
+// newPipeline resets the job's steps to pending.
+func (p *Processor) newPipeline(ctx context.Context, job *models.BuildJob) *pipeline {
+	pl := &pipeline{p: p, jobID: job.ID, lease: job.LeaseOwner, current: -1}
+	for _, name := range models.BuildStepNames {
+		pl.steps = append(pl.steps, models.BuildStep{Name: name, Status: models.StepPending})
+	}
+	pl.save(ctx)
+	return pl
+}
+
+func (pl *pipeline) index(name string) int {
+	for i := range pl.steps {
+		if pl.steps[i].Name == name {
+			return i
+		}
+	}
+	panic("unknown build step " + name)
+}
+
+// start marks name as running; output logged by the step is tagged with it.
+func (pl *pipeline) start(ctx context.Context, name string) {
+	i := pl.index(name)
+	now := time.Now()
+	pl.steps[i].Status = models.StepRunning
+	pl.steps[i].StartedAt = &now
+	pl.current = i
+	pl.save(ctx)
+}
+
+// finish ends the running step, as an error if err is non-nil.
+func (pl *pipeline) finish(ctx context.Context, err error) {
+	if err != nil {
+		pl.end(ctx, models.StepError, err.Error())
+		return
+	}
+	pl.end(ctx, models.StepSuccess, "")
+}
+
+// end closes the running step with an explicit status and note.
+func (pl *pipeline) end(ctx context.Context, status models.StepStatus, msg string) {
+	if pl.current < 0 {
+		return
+	}
+	s := &pl.steps[pl.current]
+	now := time.Now()
+	s.Status = status
+	s.EndedAt = &now
+	if s.StartedAt != nil {
+		s.DurationMs = now.Sub(*s.StartedAt).Milliseconds()
+	}
+	s.Error = msg
+	pl.current = -1
+	pl.save(ctx)
+}
+
+// skip marks a step that does not apply to this build.
+func (pl *pipeline) skip(ctx context.Context, name string) {
+	pl.steps[pl.index(name)].Status = models.StepSkipped
+	pl.save(ctx)
+}
+
+// halt stops the pipeline early: the running step (if any) ends with status
+// and every step that never started is marked skipped.
+func (pl *pipeline) halt(ctx context.Context, status models.StepStatus, err error) {
+	msg := ""
+	if err != nil {
+		msg = err.Error()
+	}
+	pl.end(ctx, status, msg)
+	for i := range pl.steps {
+		if pl.steps[i].Status == models.StepPending {
+			pl.steps[i].Status = models.StepSkipped
+		}
+	}
+	pl.save(ctx)
+}
+
+// logPush is Processor.logPush with the line tagged by the running step.
+func (pl *pipeline) logPush(ctx context.Context, id primitive.ObjectID, msg string) {
+	step := ""
+	if pl.current >= 0 {
+		step = pl.steps[pl.current].Name
+	}
+	if err := pl.p.logs.Append(ctx, id, buildlog.System, step, msg); err != nil {
+		fmt.Printf("[WORKER] job %s: could not store log line: %v\n", id.Hex(), err)
+	}
+}
+
+func (pl *pipeline) save(ctx context.Context) {
+	jobs := pl.p.collection("build_jobs")
+	if jobs == nil {
+		return
+	}
+	// a run that lost the lease must not overwrite the new owner's steps
+	_, err := jobs.UpdateOne(ctx,
+		bson.M{"_id": pl.jobID, "leaseOwner": pl.lease},
+		bson.M{"$set": bson.M{"steps": pl.steps, "updatedAt": time.Now()}},
+	)
+	if err != nil {
+		fmt.Printf("[WORKER] job %s: could not record steps: %v\n", pl.jobID.Hex(), err)
+	}
+}
@@ -2,66 +2,202 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/rishyym0927/storehubx/internal/buildlog"
 	"github.com/rishyym0927/storehubx/internal/db"
+	"github.com/rishyym0927/storehubx/internal/gc"
 	"github.com/rishyym0927/storehubx/internal/models"
 	"github.com/rishyym0927/storehubx/internal/storage"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type Processor struct {
 	uploader storage.Uploader
 	tmpDir   string
+
+	queue    Queue
+	logs     *buildlog.Store
+	source   Source
+	workerID string
+	lease    time.Duration
+
+	concurrency  int           // build slots run in parallel
+	stepTimeout  time.Duration // wall-clock limit for each build step
+	drainTimeout time.Duration // grace period for in-flight builds on shutdown
+	aborting     atomic.Bool   // set once in-flight builds are being cut short
+
+	commitStatus bool   // report results to GitHub as commit statuses
+	frontendURL  string // dashboard base URL used as status target
+	sourceLimit  int64  // max uncompressed bytes of a source snapshot
+
+	gcInterval time.Duration // how often to collect unreferenced files; 0 = never
+	gcGrace    time.Duration // unreferenced files younger than this are kept
+	collecting atomic.Bool   // set while a garbage collection pass runs
+}
+
+// Option customises a Processor.
+type Option func(*Processor)
+
+// WithQueue replaces the default MongoDB-backed job queue.
+func WithQueue(q Queue) Option {
+	return func(p *Processor) { p.queue = q }
+}
+
+// WithLogStore replaces the default build_logs store.
+func WithLogStore(s *buildlog.Store) Option {
+	return func(p *Processor) { p.logs = s }
+}
+
+// WithSource replaces GitHubSource as the way repositories are fetched.
+func WithSource(s Source) Option {
+	return func(p *Processor) { p.source = s }
 }
 
-func NewProcessor(uploader storage.Uploader) *Processor {
+// NewProcessor reads its settings from the environment. When db.Init was not
+// called, the processor runs without a database: the queue and log store
+// default to in-memory ones, and version state, build steps, dist-tags and
+// GitHub commit statuses are skipped. Together with a MemoryUploader and
+// ZipFileSource this runs the whole pipeline with no external services.
+func NewProcessor(uploader storage.Uploader, opts ...Option) *Processor {
 	tmp := os.Getenv("BUILD_TMP_DIR")
 	if tmp == "" {
 		tmp = os.TempDir()
 	}
-	return &Processor{uploader: uploader, tmpDir: tmp}
+	leaseSec, _ := strconv.Atoi(os.Getenv("JOB_LEASE_SECONDS"))
+	if leaseSec <= 0 {
+		leaseSec = 60
+	}
+	maxAttempts, _ := strconv.Atoi(os.Getenv("JOB_MAX_ATTEMPTS"))
+	perOwner, _ := strconv.Atoi(os.Getenv("MAX_BUILDS_PER_OWNER"))
+	concurrency, _ := strconv.Atoi(os.Getenv("MAX_CONCURRENT_BUILDS"))
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	stepMin, _ := strconv.Atoi(os.Getenv("BUILD_TIMEOUT_MINUTES"))
+	if stepMin <= 0 {
+		stepMin = 10
+	}
+	drainSec, _ := strconv.Atoi(os.Getenv("WORKER_DRAIN_TIMEOUT_SECONDS"))
+	if drainSec <= 0 {
+		drainSec = 120
+	}
+	sourceMB, _ := strconv.Atoi(os.Getenv("SOURCE_MAX_MB"))
+	if sourceMB <= 0 {
+		sourceMB = 50
+	}
+	gcHours, _ := strconv.Atoi(os.Getenv("GC_INTERVAL_HOURS"))
+	gcGraceHours, _ := strconv.Atoi(os.Getenv("GC_GRACE_HOURS"))
+	if gcGraceHours <= 0 {
+		gcGraceHours = 24
+	}
+	frontendURL := strings.TrimRight(os.Getenv("FRONTEND_URL"), "/")
+	if frontendURL == "" {
+		frontendURL = "http://localhost:3000"
+	}
+	host, _ := os.Hostname()
+
+	p := &Processor{
+		uploader:     uploader,
+		tmpDir:       tmp,
+		workerID:     fmt.Sprintf("%s-%d", firstNonEmpty(host, "worker"), os.Getpid()),
+		lease:        time.Duration(leaseSec) * time.Second,
+		concurrency:  concurrency,
+		stepTimeout:  time.Duration(stepMin) * time.Minute,
+		drainTimeout: time.Duration(drainSec) * time.Second,
+		commitStatus: os.Getenv("GITHUB_COMMIT_STATUS") != "false",
+		frontendURL:  frontendURL,
+		sourceLimit:  int64(sourceMB) << 20,
+		source:       GitHubSource,
+		gcInterval:   time.Duration(gcHours) * time.Hour,
+		gcGrace:      time.Duration(gcGraceHours) * time.Hour,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if db.Client == nil {
+		p.commitStatus = false // statuses are posted with the owner's stored token
+		if p.logs == nil {
+			p.logs = buildlog.NewMemoryStore()
+		}
+		if p.queue == nil {
+			p.queue = NewMemoryQueue(maxAttempts)
+		}
+	}
+	if p.logs == nil {
+		p.logs = buildlog.NewStore(p.collection("build_logs"))
+	}
+	if p.queue == nil {
+		col := p.collection("build_jobs")
+		p.queue = NewMongoQueue(col, MongoQueueConfig{
+			MaxAttempts: maxAttempts,
+			// jobs claimed by workers that predate leases are stale after 10 lease periods without updates
+			StaleAfter:         10 * p.lease,
+			MaxRunningPerOwner: perOwner,
+			Logs:               p.logs,
+		})
+	}
+	return p
+}
+
+// collection returns one of the worker's collections, or nil when it runs
+// without a database.
+func (p *Processor) collection(name string) *mongo.Collection {
+	if db.Client == nil {
+		return nil
+	}
+	return db.Client.Database(os.Getenv("MONGO_DB")).Collection(name)
 }
 
 func (p *Processor) logPush(ctx context.Context, id primitive.ObjectID, msg string) {
-	_, _ = db.Client.Database(os.Getenv("MONGO_DB")).
-		Collection("build_jobs").
-		UpdateByID(ctx, id, bson.M{"$set": bson.M{"updatedAt": time.Now()}, "$push": bson.M{"logs": msg}})
+	if err := p.logs.Append(ctx, id, buildlog.System, "", msg); err != nil {
+		fmt.Printf("[WORKER] job %s: could not store log line: %v\n", id.Hex(), err)
+	}
+}
+
+// leaseToken names one claim. Every claim gets its own, so a build that
+// outlived its lease cannot renew or finish the job after this same worker
+// claimed it again.
+func (p *Processor) leaseToken() string {
+	return p.workerID + "/" + primitive.NewObjectID().Hex()
 }
 
-func (p *Processor) setStatus(ctx context.Context, id primitive.ObjectID, status models.BuildStatus, extra bson.M) {
-	set := bson.M{"status": status, "updatedAt": time.Now()}
-	for k, v := range extra {
-		set[k] = v
+// complete records the job's final status. It returns false when the lease was
+// lost, in which case another worker owns the job and nothing else may be touched.
+func (p *Processor) complete(ctx context.Context, job *models.BuildJob, status models.BuildStatus, extra bson.M) bool {
+	if err := p.queue.Complete(ctx, job.ID, job.LeaseOwner, status, extra); err != nil {
+		fmt.Printf("[WORKER] job %s: could not record status %s: %v\n", job.ID.Hex(), status, err)
+		return false
 	}
-	_, _ = db.Client.Database(os.Getenv("MONGO_DB")).
-		Collection("build_jobs").
-		UpdateByID(ctx, id, bson.M{"$set": set})
+	return true
 }
 
-func (p *Processor) claimQueued(ctx context.Context) (*models.BuildJob, error) {
-	var job models.BuildJob
-	now := time.Now()
-	err := db.Client.Database(os.Getenv("MONGO_DB")).
-		Collection("build_jobs").
-		FindOneAndUpdate(ctx,
-			bson.M{"status": models.BuildQueued},
-			bson.M{"$set": bson.M{"status": models.BuildRunning, "startedAt": now, "updatedAt": now}},
-			options.FindOneAndUpdate().SetReturnDocument(options.After),
-		).Decode(&job)
-	if err != nil {
-		return nil, err
+func (p *Processor) setVersionState(ctx context.Context, job *models.BuildJob, set bson.M) {
+	if job.PullRequest != nil {
+		return // previews have no version document
+	}
+	verCol := p.collection("component_versions")
+	if verCol == nil {
+		return
 	}
-	return &job, nil
+	_, _ = verCol.UpdateOne(ctx,
+		bson.M{"componentId": job.ComponentID, "version": job.Version},
+		bson.M{"$set": set},
+	)
 }
 
+// Run claims and builds jobs until ctx is canceled, then drains: in-flight
+// builds get drainTimeout to finish, after which they are aborted and requeued.
 func (p *Processor) Run(ctx context.Context) {
 	pollMs, _ := strconv.Atoi(os.Getenv("JOB_POLL_INTERVAL_MS"))
 	if pollMs <= 0 {
@@ -74,46 +210,249 @@ func (p *Processor) Run(ctx context.Context) {
 	heartbeatTicker := time.NewTicker(30 * time.Second)
 	defer heartbeatTicker.Stop()
 
+	// Reaper recovers jobs whose worker died mid-build
+	reapTicker := time.NewTicker(p.lease)
+	defer reapTicker.Stop()
+
+	// Garbage collection of unreferenced files, when enabled
+	var gcTick <-chan time.Time
+	if p.gcInterval > 0 && db.Client != nil {
+		gcTicker := time.NewTicker(p.gcInterval)
+		defer gcTicker.Stop()
+		gcTick = gcTicker.C
+	}
+
+	// Builds run on their own context so a shutdown signal does not kill them outright.
+	workCtx, stopWork := context.WithCancel(context.Background())
+	defer stopWork()
+	var inFlight sync.WaitGroup
+	slots := make(chan struct{}, p.concurrency)
+
+	fmt.Printf("[WORKER] id=%s slots=%d lease=%s\n", p.workerID, p.concurrency, p.lease)
+
 	for {
 		select {
 		case <-ctx.Done():
+			p.drain(&inFlight, stopWork)
 			return
 		case <-heartbeatTicker.C:
 			// Log heartbeat with queued job count
-			count, err := db.Client.Database(os.Getenv("MONGO_DB")).
-				Collection("build_jobs").
-				CountDocuments(ctx, bson.M{"status": models.BuildQueued})
-			if err == nil {
-				fmt.Printf("[WORKER] Heartbeat: queued=%d\n", count)
+			if jobs := p.collection("build_jobs"); jobs == nil {
+				fmt.Printf("[WORKER] Heartbeat: running=%d/%d\n", len(slots), cap(slots))
+			} else if count, err := jobs.CountDocuments(ctx, bson.M{"status": models.BuildQueued}); err == nil {
+				fmt.Printf("[WORKER] Heartbeat: queued=%d running=%d/%d\n", count, len(slots), cap(slots))
+			}
+		case <-reapTicker.C:
+			p.reap(ctx)
+		case <-gcTick:
+			// a pass can take a while on a big bucket; keep claiming meanwhile
+			if !p.collecting.CompareAndSwap(false, true) {
+				fmt.Println("[WORKER] gc: previous pass still running, skipping")
+				break
 			}
+			// tracked with the builds so shutdown waits for a pass to stop
+			inFlight.Add(1)
+			go func() {
+				defer inFlight.Done()
+				defer p.collecting.Store(false)
+				p.collectGarbage(ctx)
+			}()
 		case <-ticker.C:
-			if job, err := p.claimQueued(ctx); err == nil && job != nil {
-				p.process(ctx, job)
+			// fill every free slot before waiting for the next tick
+			for len(slots) < cap(slots) && ctx.Err() == nil {
+				job, err := p.queue.Claim(ctx, p.leaseToken(), p.lease)
+				if err != nil {
+					if ctx.Err() == nil {
+						fmt.Printf("[WORKER] claim failed: %v\n", err)
+					}
+					break
+				}
+				if job == nil {
+					break
+				}
+				slots <- struct{}{}
+				inFlight.Add(1)
+				go func() {
+					defer inFlight.Done()
+					defer func() { <-slots }()
+					p.process(workCtx, job)
+				}()
+			}
+		}
+	}
+}
+
+// RunOnce claims one job and builds it before returning. It returns the
+// claimed job as it was when claimed, or nil when nothing was queued.
+func (p *Processor) RunOnce(ctx context.Context) (*models.BuildJob, error) {
+	job, err := p.queue.Claim(ctx, p.leaseToken(), p.lease)
+	if err != nil || job == nil {
+		return nil, err
+	}
+	claimed := *job
+	p.process(ctx, job)
+	return &claimed, nil
+}
+
+// drain waits for in-flight builds and any garbage collection pass, which
+// stops as soon as ctx is canceled. Builds that outlive drainTimeout are
+// aborted, and process hands them back to the queue instead of failing them.
+func (p *Processor) drain(inFlight *sync.WaitGroup, stopWork context.CancelFunc) {
+	done := make(chan struct{})
+	go func() {
+		inFlight.Wait()
+		close(done)
+	}()
+
+	fmt.Printf("[WORKER] shutting down: waiting up to %s for in-flight builds\n", p.drainTimeout)
+	select {
+	case <-done:
+		return
+	case <-time.After(p.drainTimeout):
+	}
+
+	fmt.Println("[WORKER] drain timeout reached: aborting and requeueing in-flight builds")
+	p.aborting.Store(true)
+	stopWork()
+	<-done
+}
+
+// reap requeues or fails jobs with expired leases and keeps their versions in sync.
+func (p *Processor) reap(ctx context.Context) {
+	reaped, err := p.queue.ReapExpired(ctx)
+	if err != nil {
+		fmt.Printf("[WORKER] reaper failed: %v\n", err)
+	}
+	for i := range reaped {
+		job := &reaped[i].Job
+		switch {
+		case reaped[i].Requeued:
+			fmt.Printf("[WORKER] reaper: requeued job %s (attempt %d/%d)\n", job.ID.Hex(), job.Attempts, job.MaxAttempts)
+			p.setVersionState(ctx, job, bson.M{"buildState": models.VersionBuildQueued})
+		case job.CancelRequested:
+			fmt.Printf("[WORKER] reaper: job %s canceled\n", job.ID.Hex())
+			p.setVersionState(ctx, job, bson.M{"buildState": models.VersionBuildCanceled})
+			p.reportStatus(ctx, job, statusError, "Build canceled", p.componentPage(job))
+		default:
+			fmt.Printf("[WORKER] reaper: job %s failed after %d attempts\n", job.ID.Hex(), job.Attempts)
+			p.setVersionState(ctx, job, bson.M{"buildState": models.VersionBuildError})
+			p.reportStatus(ctx, job, statusError, "Build worker stopped responding", p.componentPage(job))
+		}
+	}
+}
+
+// collectGarbage deletes stored files nothing refers to any more. A pass is
+// cut short once gcInterval has passed, so it never overlaps the next one.
+// References are read from the API's database, like storehubx-admin gc does,
+// whatever MONGO_DB says: a database missing them would condemn live files.
+func (p *Processor) collectGarbage(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, p.gcInterval)
+	defer cancel()
+	if name := os.Getenv("MONGO_DB"); name != "" && name != db.Name {
+		fmt.Printf("[WORKER] gc: MONGO_DB is %q; collecting against the API database %q\n", name, db.Name)
+	}
+	res, err := gc.New(db.Client.Database(db.Name), p.uploader).Run(ctx, gc.Options{Grace: p.gcGrace})
+	if err != nil {
+		fmt.Printf("[WORKER] gc failed: %v\n", err)
+		return
+	}
+	deleted := 0
+	for _, a := range res.Collected {
+		if a.Error != "" {
+			fmt.Printf("[WORKER] gc: could not delete %s: %s\n", a.Prefix, a.Error)
+			continue
+		}
+		deleted++
+		fmt.Printf("[WORKER] gc: deleted %s (%s, %d bytes)\n", a.Prefix, a.Reason, a.Bytes)
+	}
+	fmt.Printf("[WORKER] gc: scanned %d objects, deleted %d prefixes, reclaimed %d bytes\n", res.Scanned, deleted, res.Reclaimed)
+}
+
+// keepLease renews the job's lease until ctx ends. The build is aborted with the
+// matching cause if the lease is lost or the owner cancels the job.
+func (p *Processor) keepLease(ctx context.Context, job *models.BuildJob, abort context.CancelCauseFunc) {
+	// beat often enough that cancellation feels prompt, and at least 3x per lease
+	interval := min(p.lease/3, 5*time.Second)
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			err := p.queue.Heartbeat(ctx, job.ID, job.LeaseOwner, p.lease)
+			switch {
+			case errors.Is(err, ErrLeaseLost):
+				fmt.Printf("[WORKER] job %s: lease lost, aborting build\n", job.ID.Hex())
+				abort(err)
+				return
+			case errors.Is(err, ErrCancelRequested):
+				fmt.Printf("[WORKER] job %s: cancel requested, stopping build\n", job.ID.Hex())
+				abort(err)
+				return
+			case err != nil:
+				fmt.Printf("[WORKER] job %s: heartbeat failed: %v\n", job.ID.Hex(), err)
 			}
 		}
 	}
 }
 
 func (p *Processor) process(ctx context.Context, job *models.BuildJob) {
+	// buildCtx is aborted if the lease is lost or the worker gives up draining;
+	// bookkeeping uses a context that survives both so the outcome is recorded.
+	buildCtx, abort := context.WithCancelCause(ctx)
+	defer abort(nil)
+	defer p.logs.Forget(job.ID)
+	ctx = context.WithoutCancel(ctx)
+	go p.keepLease(buildCtx, job, abort)
+
 	jobID := job.ID
-	p.logPush(ctx, jobID, "picked by worker")
+	p.logPush(ctx, jobID, fmt.Sprintf("picked by worker %s (attempt %d/%d)", p.workerID, job.Attempts, job.MaxAttempts))
+	p.setVersionState(ctx, job, bson.M{"buildState": models.VersionBuildRunning})
+	pl := p.newPipeline(ctx, job)
+	p.reportStatus(ctx, job, statusPending, "Building preview...", p.componentPage(job))
+
+	// fail records err unless the build was stopped on purpose
+	fail := func(err error) {
+		if errors.Is(context.Cause(buildCtx), ErrCancelRequested) {
+			pl.halt(ctx, models.StepCanceled, nil)
+			p.canceled(ctx, job)
+			return
+		}
+		if errors.Is(context.Cause(buildCtx), ErrLeaseLost) {
+			// the job belongs to another run now; its steps, log and archive are not ours
+			fmt.Printf("[WORKER] job %s: dropped after losing the lease: %v\n", jobID.Hex(), err)
+			return
+		}
+		if !p.aborting.Load() {
+			// a released job starts over with fresh steps when it is claimed again
+			pl.halt(ctx, models.StepError, err)
+		}
+		p.fail(ctx, job, err)
+	}
+
 	workRoot := filepath.Join(p.tmpDir, "job-"+jobID.Hex())
 	_ = os.RemoveAll(workRoot)
 	_ = os.MkdirAll(workRoot, 0o755)
 
 	// 1) Download zipball
-	p.logPush(ctx, jobID, "downloading repository zip...")
-	zipPath, err := downloadRepoZip(ctx, workRoot, job.Repo.Owner, job.Repo.Repo, firstNonEmpty(job.Repo.Commit, job.Repo.Ref, "main"), job.OwnerID)
+	pl.start(ctx, models.StepDownload)
+	pl.logPush(ctx, jobID, "downloading repository zip...")
+	dlCtx, dlCancel := context.WithTimeout(buildCtx, p.stepTimeout)
+	zipPath, err := p.source(dlCtx, job, workRoot)
+	dlCancel()
 	if err != nil {
-		p.fail(ctx, job, fmt.Errorf("download failed: %w", err))
+		fail(fmt.Errorf("download failed: %w", err))
 		return
 	}
+	pl.finish(ctx, nil)
 
 	// 2) Unzip
-	p.logPush(ctx, jobID, "extracting zip...")
+	pl.start(ctx, models.StepExtract)
+	pl.logPush(ctx, jobID, "extracting zip...")
 	topDir, err := unzip(zipPath, workRoot)
 	if err != nil {
-		p.fail(ctx, job, fmt.Errorf("unzip failed: %w", err))
+		fail(fmt.Errorf("unzip failed: %w", err))
 		return
 	}
 
@@ -123,65 +462,138 @@ func (p *Processor) process(ctx context.Context, job *models.BuildJob) {
 		working = filepath.Join(topDir, job.Repo.Path)
 	}
 	if _, err := os.Stat(working); err != nil {
-		p.fail(ctx, job, fmt.Errorf("invalid path in repo: %s", job.Repo.Path))
+		fail(fmt.Errorf("invalid path in repo: %s", job.Repo.Path))
 		return
 	}
+	pl.finish(ctx, nil)
+
+	// 3b) Snapshot the source installs deliver, before the build touches it
+	var snap *sourceSnapshot
+	if job.PullRequest != nil {
+		pl.skip(ctx, models.StepSnapshot) // previews are not installable
+	} else {
+		pl.start(ctx, models.StepSnapshot)
+		snap, err = p.snapshotSource(job, working, workRoot)
+		if err != nil {
+			fail(fmt.Errorf("source snapshot failed: %w", err))
+			return
+		}
+		pl.logPush(ctx, jobID, fmt.Sprintf("source: %d files, %d bytes, %s", len(snap.manifest.Files), snap.manifest.Size, snap.manifest.Integrity))
+		pl.finish(ctx, nil)
+	}
 
 	// 4) Try to build
+	cacheDir := p.depCacheDir(job.Component)
+	if job.ClearCache {
+		p.logPush(ctx, jobID, "clearing dependency cache...")
+		_ = os.RemoveAll(cacheDir)
+	}
 	p.logPush(ctx, jobID, "running build (npm) or static fallback...")
-	if err := p.maybeBuildWithNode(ctx, jobID, working); err != nil {
-		p.fail(ctx, job, err)
+	if err := p.maybeBuildWithNode(buildCtx, pl, working, []string{"npm_config_cache=" + cacheDir}); err != nil {
+		fail(err)
 		return
 	}
 
 	// 5) Pick output folder
 	outDir, err := pickOutputDir(working)
 	if err != nil {
-		p.fail(ctx, job, err)
+		fail(err)
 		return
 	}
 
 	// 6) Modify index.html BEFORE upload
-	p.logPush(ctx, jobID, "[STEP] Modifying index.html...")
+	pl.start(ctx, models.StepRewrite)
+	pl.logPush(ctx, jobID, "[STEP] Modifying index.html...")
 	indexPath := filepath.Join(outDir, "index.html")
-	if err := modifyIndexHTMLOnDisk(ctx, jobID, indexPath, job, p.logPush); err != nil {
-		p.logPush(ctx, jobID, fmt.Sprintf("[WARN] index.html modification skipped: %v", err.Error()))
+	if err := modifyIndexHTMLOnDisk(ctx, jobID, indexPath, job, pl.logPush); err != nil {
+		pl.logPush(ctx, jobID, fmt.Sprintf("[WARN] index.html modification skipped: %v", err.Error()))
+		pl.end(ctx, models.StepSkipped, err.Error())
+	} else {
+		pl.finish(ctx, nil)
 	}
 
 	// 7) Upload files using PublishComponentFromDist (handles path rewriting)
-	p.logPush(ctx, jobID, "[STEP] Uploading files to S3 and rewriting asset paths...")
-	bundleURL, err := p.uploader.PublishComponentFromDist(ctx, job.Component, job.Version, outDir)
+	pl.start(ctx, models.StepUpload)
+	pl.logPush(ctx, jobID, "[STEP] Uploading files to S3 and rewriting asset paths...")
+	upCtx, upCancel := context.WithTimeout(buildCtx, p.stepTimeout)
+	bundleURL, err := p.uploader.PublishComponentFromDist(upCtx, job.Component, job.Version, outDir)
+	var source *models.SourceArtifact
+	if err == nil && snap != nil {
+		source, err = p.publishSource(upCtx, job, snap)
+	}
+	upCancel()
 	if err != nil {
-		p.fail(ctx, job, fmt.Errorf("upload failed: %w", err))
+		fail(fmt.Errorf("upload failed: %w", err))
+		return
+	}
+	if errors.Is(context.Cause(buildCtx), ErrLeaseLost) {
+		fmt.Printf("[WORKER] job %s: dropped after losing the lease\n", jobID.Hex())
 		return
 	}
-	p.logPush(ctx, jobID, fmt.Sprintf("[SUCCESS] Files uploaded. Bundle URL: %s", bundleURL))
+	pl.logPush(ctx, jobID, fmt.Sprintf("[SUCCESS] Files uploaded. Bundle URL: %s", bundleURL))
+	pl.finish(ctx, nil)
+	p.logPush(ctx, jobID, "build complete")
 
 	// 6) Update job success
-	p.setStatus(ctx, jobID, models.BuildSuccess, bson.M{
+	if !p.complete(ctx, job, models.BuildSuccess, bson.M{
 		"endedAt":   time.Now(),
-		"artifacts": models.BuildArtifact{BundleURL: bundleURL},
-	})
-	p.logPush(ctx, jobID, "build complete")
+		"artifacts": models.BuildArtifact{BundleURL: bundleURL, LogURL: p.archiveLogs(ctx, job)},
+	}) {
+		return
+	}
 
 	// 7) Patch version with previewUrl + set build state
-	verCol := db.Client.Database(os.Getenv("MONGO_DB")).Collection("component_versions")
-	_, _ = verCol.UpdateOne(ctx,
-		bson.M{"componentId": job.ComponentID, "version": job.Version},
-		bson.M{"$set": bson.M{"previewUrl": bundleURL, "buildState": models.VersionBuildReady}},
-	)
+	p.setVersionState(ctx, job, bson.M{"previewUrl": bundleURL, "buildState": models.VersionBuildReady, "source": source})
+	p.promoteLatest(ctx, job)
+	p.reportStatus(ctx, job, statusSuccess, "Preview ready", bundleURL)
+	p.commentPreview(ctx, job, fmt.Sprintf("**StoreHUBX preview for `%s`** is ready: %s\n\nBuilt from %s.", job.Component, bundleURL, job.Repo.Commit))
 }
 
 func (p *Processor) fail(ctx context.Context, job *models.BuildJob, err error) {
+	if p.aborting.Load() {
+		// the failure was caused by the shutdown itself; let another worker retry
+		if rerr := p.queue.Release(ctx, job.ID, job.LeaseOwner, "worker "+p.workerID+" shut down mid-build"); rerr == nil {
+			p.setVersionState(ctx, job, bson.M{"buildState": models.VersionBuildQueued})
+		}
+		return
+	}
+
 	p.logPush(ctx, job.ID, "ERROR: "+err.Error())
-	p.setStatus(ctx, job.ID, models.BuildError, bson.M{"endedAt": time.Now()})
+	fields := p.finalFields(ctx, job)
+	if !p.complete(ctx, job, models.BuildError, fields) {
+		return
+	}
 
 	// Update component version status to error
-	verCol := db.Client.Database(os.Getenv("MONGO_DB")).Collection("component_versions")
-	_, _ = verCol.UpdateOne(ctx,
-		bson.M{"componentId": job.ComponentID, "version": job.Version},
-		bson.M{"$set": bson.M{"buildState": models.VersionBuildError}},
-	)
+	p.setVersionState(ctx, job, bson.M{"buildState": models.VersionBuildError})
+	p.reportStatus(ctx, job, statusFailure, "Build failed: "+err.Error(), p.logTarget(job, fields))
+	p.commentPreview(ctx, job, fmt.Sprintf("**StoreHUBX preview for `%s`** failed to build from %s: %s\n\n[Build log](%s)", job.Component, job.Repo.Commit, err.Error(), p.logTarget(job, fields)))
+}
+
+// canceled records a build stopped at the owner's request.
+func (p *Processor) canceled(ctx context.Context, job *models.BuildJob) {
+	p.logPush(ctx, job.ID, "build canceled")
+	fields := p.finalFields(ctx, job)
+	if !p.complete(ctx, job, models.BuildCanceled, fields) {
+		return
+	}
+	p.setVersionState(ctx, job, bson.M{"buildState": models.VersionBuildCanceled})
+	p.reportStatus(ctx, job, statusError, "Build canceled", p.logTarget(job, fields))
+}
+
+// finalFields are recorded on jobs that end without a bundle.
+func (p *Processor) finalFields(ctx context.Context, job *models.BuildJob) bson.M {
+	set := bson.M{"endedAt": time.Now()}
+	if url := p.archiveLogs(ctx, job); url != "" {
+		set["artifacts.logUrl"] = url
+	}
+	return set
+}
+
+// depCacheDir is the npm cache shared by all builds of one component, so
+// retries and new versions don't re-download every dependency.
+func (p *Processor) depCacheDir(component string) string {
+	return filepath.Join(p.tmpDir, "npm-cache", strings.ReplaceAll(component, "/", "__"))
 }
 
 func firstNonEmpty(vals ...string) string {
@@ -272,7 +684,7 @@ func (p *Processor) uploadFilesWithCorrectMimeTypes(ctx context.Context, jobID p
 	p.logPush(ctx, jobID, fmt.Sprintf("[SUCCESS] Uploaded %d files", fileCount))
 
 	if bundleURL == "" {
-		bundleURL = os.Getenv("S3_PUBLIC_BASE_URL") + "/" + keyPrefix + "index.html"
+		bundleURL = p.uploader.PublicURL(keyPrefix + "index.html")
 		p.logPush(ctx, jobID, fmt.Sprintf("[INFO] No index.html found, using default URL: %s", bundleURL))
 	}
 
@@ -0,0 +1,94 @@
+package worker
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/rishyym0927/storehubx/internal/models"
+	"github.com/rishyym0927/storehubx/internal/storage"
+)
+
+// TestRunOnceStaticSite runs a full build of a static component with no
+// database, GitHub or S3: the repository comes from a zip fixture and
+// everything is published to a MemoryUploader.
+func TestRunOnceStaticSite(t *testing.T) {
+	t.Setenv("BUILD_TMP_DIR", t.TempDir())
+
+	queue := NewMemoryQueue(1)
+	store := storage.NewMemoryUploader()
+	p := NewProcessor(store, WithQueue(queue), WithSource(ZipFileSource("testdata/static-site.zip")))
+
+	id := queue.Enqueue(models.BuildJob{
+		Component: "button",
+		Version:   "1.0.0",
+		OwnerID:   "owner-1",
+		Repo:      models.BuildRepo{Owner: "acme", Repo: "widgets", Path: "button", Commit: "1a2b3c4"},
+	})
+
+	ctx := context.Background()
+	claimed, err := p.RunOnce(ctx)
+	if err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if claimed == nil || claimed.ID != id {
+		t.Fatalf("RunOnce claimed %v, want job %s", claimed, id.Hex())
+	}
+
+	job, _ := queue.Job(id)
+	logKey := LogArchiveKey(id)
+	if job.Status != models.BuildSuccess {
+		obj, _ := store.Object(logKey)
+		t.Fatalf("job status = %s, want %s; log:\n%s", job.Status, models.BuildSuccess, obj.Data)
+	}
+	if want := store.PublicURL("components/button/1.0.0/index.html"); job.Artifacts.BundleURL != want {
+		t.Errorf("bundle URL = %q, want %q", job.Artifacts.BundleURL, want)
+	}
+	if want := store.PublicURL(logKey); job.Artifacts.LogURL != want {
+		t.Errorf("log URL = %q, want %q", job.Artifacts.LogURL, want)
+	}
+
+	want := map[string]string{
+		"components/button/1.0.0/index.html":            "text/html",
+		"components/button/1.0.0/assets/button.css":     "text/css",
+		"components/button/1.0.0/assets/button.js":      "application/javascript",
+		"components/button/1.0.0/assets/logo.svg":       "image/svg+xml",
+		"components/button/1.0.0/_source/source.tgz":    "application/gzip",
+		"components/button/1.0.0/_source/manifest.json": "application/json",
+		logKey: "text/plain; charset=utf-8",
+	}
+	objects := store.Objects("")
+	for _, obj := range objects {
+		ct, ok := want[obj.Key]
+		if !ok {
+			t.Errorf("unexpected object %s", obj.Key)
+			continue
+		}
+		if obj.ContentType != ct {
+			t.Errorf("%s: content type %q, want %q", obj.Key, obj.ContentType, ct)
+		}
+		delete(want, obj.Key)
+	}
+	for key := range want {
+		t.Errorf("missing object %s", key)
+	}
+
+	index, _ := store.Object("components/button/1.0.0/index.html")
+	for _, ref := range []string{`href="assets/button.css"`, `src="assets/button.js"`} {
+		if !strings.Contains(string(index.Data), ref) {
+			t.Errorf("index.html does not reference %s:\n%s", ref, index.Data)
+		}
+	}
+	manifest, _ := store.Object("components/button/1.0.0/_source/manifest.json")
+	if !strings.Contains(string(manifest.Data), `"commit": "1a2b3c4"`) {
+		t.Errorf("source manifest does not record the commit:\n%s", manifest.Data)
+	}
+	log, _ := store.Object(logKey)
+	if !strings.Contains(string(log.Data), "build complete") {
+		t.Errorf("archived log does not end the build:\n%s", log.Data)
+	}
+
+	if next, err := p.RunOnce(ctx); err != nil || next != nil {
+		t.Errorf("RunOnce on an empty queue = %v, %v; want nil, nil", next, err)
+	}
+}